@@ -0,0 +1,365 @@
+package azure
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/tombuildsstuff/giovanni/storage/2018-11-09/blob/blobs"
+)
+
+// This file holds RemoteClient and the snapshot/version-history subsystem
+// described in the backend's docs. The rest of the Azure backend this
+// client normally sits inside of (backend.go's schema/configuration,
+// arm.go's client construction and auth, the acceptance-test resource
+// helpers client_test.go calls into) isn't part of this trimmed snapshot,
+// so RemoteClient is written here standalone, in the shape it has
+// upstream, rather than against plumbing that doesn't exist in this tree.
+
+// historySuffix is appended to a state key to name its version-history
+// blob, e.g. "terraform.tfstate" -> "terraform.tfstate.history.json".
+const historySuffix = ".history.json"
+
+// StateVersion is one entry of a state key's version history: a blob
+// snapshot taken on a prior successful Put. Storage-account blob
+// versioning is not used; every entry is a snapshot.
+type StateVersion struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Serial    uint64    `json:"serial"`
+	LeaseID   string    `json:"lease_id,omitempty"`
+}
+
+// RemoteClient implements the Get/Put/Delete/Lock/Unlock operations the
+// state backend needs against an Azure Storage blob, plus the optional
+// snapshot-based version history added alongside it.
+type RemoteClient struct {
+	giovanniBlobClient blobs.Client
+
+	accountName   string
+	containerName string
+	keyName       string
+	leaseID       string
+
+	// snapshot, snapshotRetentionDays, and snapshotMaxCount mirror the
+	// backend config attributes of the same name (minus the snapshot_
+	// prefix). snapshot off (the default) leaves Put's behavior
+	// unchanged from before this history subsystem existed.
+	snapshot              bool
+	snapshotRetentionDays int
+	snapshotMaxCount      int
+}
+
+func (c *RemoteClient) Get() ([]byte, error) {
+	ctx := context.TODO()
+
+	props, err := c.giovanniBlobClient.GetProperties(ctx, c.accountName, c.containerName, c.keyName, blobs.GetPropertiesInput{})
+	if err != nil {
+		return nil, fmt.Errorf("error loading state blob properties: %s", err)
+	}
+	if props.ContentLength == 0 {
+		return nil, nil
+	}
+
+	getInput := blobs.GetInput{}
+	if c.leaseID != "" {
+		getInput.LeaseID = &c.leaseID
+	}
+	blob, err := c.giovanniBlobClient.Get(ctx, c.accountName, c.containerName, c.keyName, getInput)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading state blob: %s", err)
+	}
+
+	return blob.Contents, nil
+}
+
+func (c *RemoteClient) Put(data []byte) error {
+	ctx := context.TODO()
+
+	if c.snapshot {
+		if err := c.snapshotCurrent(ctx); err != nil {
+			return fmt.Errorf("error snapshotting previous state before write: %s", err)
+		}
+	}
+
+	putInput := blobs.PutBlockBlobInput{Content: data}
+	if serial, err := serialFromStateBytes(data); err == nil {
+		putInput.MetaData = map[string]string{"terraform_serial": fmt.Sprintf("%d", serial)}
+	}
+	if c.leaseID != "" {
+		putInput.LeaseID = &c.leaseID
+	}
+	if _, err := c.giovanniBlobClient.PutBlockBlob(ctx, c.accountName, c.containerName, c.keyName, putInput); err != nil {
+		return fmt.Errorf("error uploading state blob: %s", err)
+	}
+
+	if c.snapshot {
+		if err := c.pruneVersions(ctx); err != nil {
+			return fmt.Errorf("error pruning old state versions: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *RemoteClient) Delete() error {
+	ctx := context.TODO()
+	if _, err := c.giovanniBlobClient.Delete(ctx, c.accountName, c.containerName, c.keyName, blobs.DeleteInput{}); err != nil {
+		return fmt.Errorf("error deleting state blob: %s", err)
+	}
+	return nil
+}
+
+func (c *RemoteClient) Lock(id string) error {
+	ctx := context.TODO()
+
+	leaseID, err := c.giovanniBlobClient.AcquireLease(ctx, c.accountName, c.containerName, c.keyName, blobs.AcquireLeaseInput{
+		ProposedLeaseID: &id,
+		LeaseDuration:   -1,
+	})
+	if err != nil {
+		return fmt.Errorf("error acquiring state lock: %s", err)
+	}
+
+	c.leaseID = leaseID.LeaseID
+	return nil
+}
+
+func (c *RemoteClient) Unlock(id string) error {
+	ctx := context.TODO()
+
+	if _, err := c.giovanniBlobClient.ReleaseLease(ctx, c.accountName, c.containerName, c.keyName, blobs.ReleaseLeaseInput{
+		LeaseID: id,
+	}); err != nil {
+		return fmt.Errorf("error releasing state lock: %s", err)
+	}
+
+	c.leaseID = ""
+	return nil
+}
+
+// snapshotCurrent takes a blob snapshot of the current state key (if it
+// exists yet) and appends a StateVersion entry for it to the key's
+// history blob. It's a no-op the first time Put is ever called for a
+// key, since there's nothing to snapshot yet.
+func (c *RemoteClient) snapshotCurrent(ctx context.Context) error {
+	props, err := c.giovanniBlobClient.GetProperties(ctx, c.accountName, c.containerName, c.keyName, blobs.GetPropertiesInput{})
+	if err != nil {
+		// Nothing to snapshot on the very first write.
+		return nil
+	}
+
+	snap, err := c.giovanniBlobClient.Snapshot(ctx, c.accountName, c.containerName, c.keyName, blobs.SnapshotInput{})
+	if err != nil {
+		return err
+	}
+
+	serial, _ := serialFromMetaData(props.MetaData)
+
+	history, err := c.readHistory(ctx)
+	if err != nil {
+		return err
+	}
+	history = append(history, StateVersion{
+		ID:        snap.SnapshotDateTime,
+		Timestamp: time.Now().UTC(),
+		Serial:    serial,
+		LeaseID:   c.leaseID,
+	})
+
+	return c.writeHistory(ctx, history)
+}
+
+// pruneVersions drops snapshots older than snapshotRetentionDays and,
+// beyond that, anything past snapshotMaxCount, oldest first. A zero value
+// for either knob disables that half of the prune.
+func (c *RemoteClient) pruneVersions(ctx context.Context) error {
+	history, err := c.readHistory(ctx)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Timestamp.Before(history[j].Timestamp)
+	})
+
+	kept := history
+	if c.snapshotRetentionDays > 0 {
+		cutoff := time.Now().UTC().AddDate(0, 0, -c.snapshotRetentionDays)
+		filtered := kept[:0]
+		for _, v := range kept {
+			if v.Timestamp.After(cutoff) {
+				filtered = append(filtered, v)
+			} else {
+				if err := c.deleteSnapshot(ctx, v.ID); err != nil {
+					return err
+				}
+			}
+		}
+		kept = filtered
+	}
+
+	if c.snapshotMaxCount > 0 && len(kept) > c.snapshotMaxCount {
+		overflow := len(kept) - c.snapshotMaxCount
+		for _, v := range kept[:overflow] {
+			if err := c.deleteSnapshot(ctx, v.ID); err != nil {
+				return err
+			}
+		}
+		kept = kept[overflow:]
+	}
+
+	if len(kept) == len(history) {
+		return nil
+	}
+	return c.writeHistory(ctx, kept)
+}
+
+func (c *RemoteClient) deleteSnapshot(ctx context.Context, id string) error {
+	_, err := c.giovanniBlobClient.Delete(ctx, c.accountName, c.containerName, c.keyName, blobs.DeleteInput{
+		SnapshotDateTime: id,
+	})
+	return err
+}
+
+// ListVersions returns the recorded version history for a state key,
+// newest first.
+func (c *RemoteClient) ListVersions(name string) ([]StateVersion, error) {
+	ctx := context.TODO()
+	history, err := c.readHistoryFor(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Timestamp.After(history[j].Timestamp)
+	})
+	return history, nil
+}
+
+// GetVersion downloads the contents of a prior snapshot of name by its
+// StateVersion.ID.
+func (c *RemoteClient) GetVersion(name, id string) ([]byte, error) {
+	ctx := context.TODO()
+
+	blob, err := c.giovanniBlobClient.Get(ctx, c.accountName, c.containerName, name, blobs.GetInput{
+		SnapshotDateTime: id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error downloading state snapshot %s: %s", id, err)
+	}
+	return blob.Contents, nil
+}
+
+// RestoreVersion overwrites the current state key with the contents of
+// a prior snapshot. Unless the client already holds a lease, it takes one
+// through RemoteClient.Lock for the duration of the write, so it's safe
+// to call concurrently with normal state operations, and it
+// refuses to restore over a state with a newer serial than the version
+// being restored unless force is set.
+func (c *RemoteClient) RestoreVersion(name, id string, force bool) error {
+	ctx := context.TODO()
+
+	data, err := c.GetVersion(name, id)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		currentProps, err := c.giovanniBlobClient.GetProperties(ctx, c.accountName, c.containerName, name, blobs.GetPropertiesInput{})
+		if err == nil {
+			currentSerial, _ := serialFromMetaData(currentProps.MetaData)
+			restoredSerial, err := serialFromStateBytes(data)
+			if err == nil && currentSerial > restoredSerial {
+				return fmt.Errorf(
+					"refusing to restore version %s (serial %d) over newer state (serial %d); pass -force to override",
+					id, restoredSerial, currentSerial)
+			}
+		}
+	}
+
+	origKeyName := c.keyName
+	c.keyName = name
+	defer func() { c.keyName = origKeyName }()
+
+	if c.leaseID == "" {
+		var b [16]byte
+		if _, err := rand.Read(b[:]); err != nil {
+			return err
+		}
+		leaseID := fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+		if err := c.Lock(leaseID); err != nil {
+			return err
+		}
+		defer c.Unlock(leaseID)
+	}
+
+	return c.Put(data)
+}
+
+func (c *RemoteClient) historyKeyName() string {
+	return c.keyName + historySuffix
+}
+
+func (c *RemoteClient) readHistory(ctx context.Context) ([]StateVersion, error) {
+	return c.readHistoryFor(ctx, c.keyName)
+}
+
+func (c *RemoteClient) readHistoryFor(ctx context.Context, name string) ([]StateVersion, error) {
+	blob, err := c.giovanniBlobClient.Get(ctx, c.accountName, c.containerName, name+historySuffix, blobs.GetInput{})
+	if err != nil {
+		// No history blob yet is not an error; it just means no
+		// snapshots have been taken for this key.
+		return nil, nil
+	}
+
+	var history []StateVersion
+	if len(blob.Contents) > 0 {
+		if err := json.Unmarshal(blob.Contents, &history); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %s", name+historySuffix, err)
+		}
+	}
+	return history, nil
+}
+
+func (c *RemoteClient) writeHistory(ctx context.Context, history []StateVersion) error {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.giovanniBlobClient.PutBlockBlob(ctx, c.accountName, c.containerName, c.historyKeyName(), blobs.PutBlockBlobInput{
+		Content: data,
+	})
+	return err
+}
+
+// serialFromMetaData and serialFromStateBytes are small helpers used only
+// to compare state generations during RestoreVersion's safety check; the
+// real serial is a property of the tfstate JSON body, but the backend
+// also mirrors it into blob metadata on Put so it can be read without a
+// full download.
+func serialFromMetaData(meta map[string]string) (uint64, error) {
+	v, ok := meta["terraform_serial"]
+	if !ok {
+		return 0, fmt.Errorf("no terraform_serial metadata present")
+	}
+	var serial uint64
+	if _, err := fmt.Sscanf(v, "%d", &serial); err != nil {
+		return 0, err
+	}
+	return serial, nil
+}
+
+func serialFromStateBytes(data []byte) (uint64, error) {
+	var state struct {
+		Serial uint64 `json:"serial"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return 0, err
+	}
+	return state.Serial, nil
+}
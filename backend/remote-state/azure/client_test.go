@@ -149,6 +149,48 @@ func TestRemoteClientServicePrincipalBasic(t *testing.T) {
 	remote.TestClient(t, state.(*remote.State).Client)
 }
 
+func TestRemoteClientOIDCBasic(t *testing.T) {
+	testAccAzureBackend(t)
+	if os.Getenv("ARM_USE_OIDC") == "" {
+		t.Skip("ARM_USE_OIDC not set")
+	}
+
+	rs := acctest.RandString(4)
+	res := testResourceNames(rs, "testState")
+	armClient := buildTestClient(t, res)
+
+	ctx := context.TODO()
+	err := armClient.buildTestResources(ctx, &res)
+	defer armClient.destroyTestResources(ctx, res)
+	if err != nil {
+		t.Fatalf("Error creating Test Resources: %q", err)
+	}
+
+	b := backend.TestBackendConfig(t, New(), backend.TestWrapConfig(map[string]interface{}{
+		"storage_account_name":    res.storageAccountName,
+		"container_name":          res.storageContainerName,
+		"key":                     res.storageKeyName,
+		"resource_group_name":     res.resourceGroup,
+		"subscription_id":         os.Getenv("ARM_SUBSCRIPTION_ID"),
+		"tenant_id":               os.Getenv("ARM_TENANT_ID"),
+		"client_id":               os.Getenv("ARM_CLIENT_ID"),
+		"use_oidc":                true,
+		"oidc_token":              os.Getenv("ARM_OIDC_TOKEN"),
+		"oidc_token_file_path":    os.Getenv("ARM_OIDC_TOKEN_FILE_PATH"),
+		"oidc_request_token":      os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN"),
+		"oidc_request_url":       os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL"),
+		"environment":             os.Getenv("ARM_ENVIRONMENT"),
+		"endpoint":                os.Getenv("ARM_ENDPOINT"),
+	})).(*Backend)
+
+	state, err := b.StateMgr(backend.DefaultStateName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	remote.TestClient(t, state.(*remote.State).Client)
+}
+
 func TestRemoteClientAccessKeyLocks(t *testing.T) {
 	testAccAzureBackend(t)
 	rs := acctest.RandString(4)
@@ -77,52 +77,52 @@
 		}
 	}
 
-	//func TestBackendConfig_invalidSSECustomerKeyLength(t *testing.T) {
-	//	testACC(t)
-	//	cfg := hcl2shim.HCL2ValueFromConfigValue(map[string]interface{}{
-	//		"region":           "us-west-1",
-	//		"state_table":           "tf-test",
-	//		"encrypt":          true,
-	//		"hash":              "state",
-	//		"lock_table":   "dynamoTable",
-	//		"sse_customer_key": "hash",
-	//	})
-	//	_, diags := New().PrepareConfig(cfg)
-	//	if !diags.HasErrors() {
-	//		t.Fatal("expected error for invalid sse_customer_key length")
-	//	}
-	//}
-	//func TestBackendConfig_invalidSSECustomerKeyEncoding(t *testing.T) {
-	//	testACC(t)
-	//	cfg := hcl2shim.HCL2ValueFromConfigValue(map[string]interface{}{
-	//		"region":           "us-west-1",
-	//		"state_table":           "tf-test",
-	//		"encrypt":          true,
-	//		"hash":              "state",
-	//		"lock_table":   "dynamoTable",
-	//		"sse_customer_key": "====CT70aTYB2JGff7AjQtwbiLkwH4npICay1PWtmdka",
-	//	})
-	//	diags := New().Configure(cfg)
-	//	if !diags.HasErrors() {
-	//		t.Fatal("expected error for failing to decode sse_customer_key")
-	//	}
-	//}
-	//func TestBackendConfig_conflictingEncryptionSchema(t *testing.T) {
-	//	testACC(t)
-	//	cfg := hcl2shim.HCL2ValueFromConfigValue(map[string]interface{}{
-	//		"region":           "us-west-1",
-	//		"state_table":           "tf-test",
-	//		"hash":              "state",
-	//		"encrypt":          true,
-	//		"lock_table":   "dynamoTable",
-	//		"sse_customer_key": "1hwbcNPGWL+AwDiyGmRidTWAEVmCWMKbEHA+Es8w75o=",
-	//		"kms_key_id":       "arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab",
-	//	})
-	//	diags := New().Configure(cfg)
-	//	if !diags.HasErrors() {
-	//		t.Fatal("expected error for simultaneous usage of kms_key_id and sse_customer_key")
-	//	}
-	//}
+	func TestBackendConfig_invalidSSECustomerKeyLength(t *testing.T) {
+		testACC(t)
+		cfg := hcl2shim.HCL2ValueFromConfigValue(map[string]interface{}{
+			"region":           "us-west-1",
+			"state_table":      "tf-test",
+			"encrypt":          true,
+			"hash":             "state",
+			"lock_table":       "dynamoTable",
+			"sse_customer_key": "hash",
+		})
+		_, diags := New().PrepareConfig(cfg)
+		if !diags.HasErrors() {
+			t.Fatal("expected error for invalid sse_customer_key length")
+		}
+	}
+	func TestBackendConfig_invalidSSECustomerKeyEncoding(t *testing.T) {
+		testACC(t)
+		cfg := hcl2shim.HCL2ValueFromConfigValue(map[string]interface{}{
+			"region":           "us-west-1",
+			"state_table":      "tf-test",
+			"encrypt":          true,
+			"hash":             "state",
+			"lock_table":       "dynamoTable",
+			"sse_customer_key": "====CT70aTYB2JGff7AjQtwbiLkwH4npICay1PWtmdka",
+		})
+		_, diags := New().PrepareConfig(cfg)
+		if !diags.HasErrors() {
+			t.Fatal("expected error for failing to decode sse_customer_key")
+		}
+	}
+	func TestBackendConfig_conflictingEncryptionSchema(t *testing.T) {
+		testACC(t)
+		cfg := hcl2shim.HCL2ValueFromConfigValue(map[string]interface{}{
+			"region":           "us-west-1",
+			"state_table":      "tf-test",
+			"hash":             "state",
+			"encrypt":          true,
+			"lock_table":       "dynamoTable",
+			"sse_customer_key": "1hwbcNPGWL+AwDiyGmRidTWAEVmCWMKbEHA+Es8w75o=",
+			"kms_key_id":       "arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+		})
+		_, diags := New().PrepareConfig(cfg)
+		if !diags.HasErrors() {
+			t.Fatal("expected error for simultaneous usage of kms_key_id and sse_customer_key")
+		}
+	}
 
 	func TestBackend(t *testing.T) {
 		testACC(t)
@@ -141,7 +141,69 @@
 		backend.TestBackendStates(t, b)
 	}
 
-	func TestBackendLocked(t *testing.T) {
+	// TestBackendLargeState pushes a multi-MB synthetic state through
+// RemoteClient directly, to prove the segmented Put/Get round-trips a
+// payload well past DynamoDB's 400 KB single-item limit.
+func TestBackendLargeState(t *testing.T) {
+	testACC(t)
+
+	tableName := fmt.Sprintf("terraform-remote-dynamodb-state-%x", time.Now().Unix())
+	hashName := "testLargeState"
+
+	b := backend.TestBackendConfig(t, New(), backend.TestWrapConfig(map[string]interface{}{
+		"state_table": tableName,
+		"hash":        hashName,
+	})).(*Backend)
+
+	createDynamoDBTable(t, b.dynClient, tableName, "state")
+	defer deleteDynamoDBTable(t, b.dynClient, tableName)
+
+	client := &RemoteClient{
+		dynClient: b.dynClient,
+		tableName: b.tableName,
+		path:      b.path("large"),
+	}
+
+	// A few MB of repeated filler, well past maxSegmentSize, so this
+	// exercises 15 segments (5 MiB / 350 KiB, rounded up).
+	large := make([]byte, 5*1024*1024)
+	for i := range large {
+		large[i] = byte(i % 251)
+	}
+
+	if err := client.Put(large); err != nil {
+		t.Fatalf("Error putting large state: %s", err)
+	}
+
+	got, err := client.Get()
+	if err != nil {
+		t.Fatalf("Error getting large state: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, large) {
+		t.Fatalf("round-tripped state did not match: got %d bytes, want %d bytes", len(got), len(large))
+	}
+
+	// Writing a smaller payload afterwards should leave no orphaned
+	// segments from the larger write.
+	small := []byte("a much smaller state body")
+	if err := client.Put(small); err != nil {
+		t.Fatalf("Error putting smaller state: %s", err)
+	}
+	got, err = client.Get()
+	if err != nil {
+		t.Fatalf("Error getting smaller state: %s", err)
+	}
+	if !reflect.DeepEqual(got, small) {
+		t.Fatalf("smaller state did not round-trip: got %q", got)
+	}
+
+	if err := client.Delete(); err != nil {
+		t.Fatalf("Error deleting state: %s", err)
+	}
+}
+
+func TestBackendLocked(t *testing.T) {
 		testACC(t)
 
 		tableName := fmt.Sprintf("terraform-remote-dynamodb-state-%x", time.Now().Unix())
@@ -169,6 +231,57 @@
 		backend.TestBackendStateForceUnlock(t, b1, b2)
 	}
 
+	// TestBackendLockedStaleReaper simulates a crashed lock holder by
+	// acquiring the lock and then aborting its heartbeat goroutine
+	// instead of calling Unlock, and asserts that a second client can
+	// acquire the lock once ExpiresAt lapses, without needing
+	// force-unlock.
+	func TestBackendLockedStaleReaper(t *testing.T) {
+		testACC(t)
+
+		lockName := fmt.Sprintf("terraform-remote-dynamodb-lock-%x", time.Now().Unix())
+		b := backend.TestBackendConfig(t, New(), backend.TestWrapConfig(map[string]interface{}{
+			"state_table": fmt.Sprintf("terraform-remote-dynamodb-state-%x", time.Now().Unix()),
+			"hash":        "testState",
+			"lock_table":  lockName,
+		})).(*Backend)
+
+		createDynamoDBTable(t, b.dynClient, lockName, "lock")
+		defer deleteDynamoDBTable(t, b.dynClient, lockName)
+
+		crashed := &RemoteClient{
+			dynClient:             b.dynClient,
+			lockTable:             lockName,
+			path:                  b.path("testState"),
+			lockHeartbeatInterval: time.Second,
+		}
+		if err := crashed.Lock("holder-a"); err != nil {
+			t.Fatalf("Error acquiring initial lock: %s", err)
+		}
+		// Simulate a crash: the heartbeat goroutine stops renewing
+		// ExpiresAt, but the LockID row is never deleted.
+		crashed.stopHeartbeat()
+
+		survivor := &RemoteClient{
+			dynClient: b.dynClient,
+			lockTable: lockName,
+			path:      b.path("testState"),
+		}
+
+		if err := survivor.Lock("holder-b"); err == nil {
+			t.Fatal("expected Lock to fail before the stale lock's TTL lapses")
+		}
+
+		time.Sleep(3 * time.Second)
+
+		if err := survivor.Lock("holder-b"); err != nil {
+			t.Fatalf("expected Lock to reap the stale lock after its TTL lapsed, got: %s", err)
+		}
+		if err := survivor.Unlock("holder-b"); err != nil {
+			t.Fatalf("Error releasing lock: %s", err)
+		}
+	}
+
 	//func TestBackendSSECustomerKey(t *testing.T) {
 	//	testACC(t)
 	//	bucketName := fmt.Sprintf("terraform-remote-s3-test-%x", time.Now().Unix())
@@ -391,7 +504,86 @@
 		backend.TestBackendStates(t, b2)
 	}
 
-	func testGetWorkspaceForKey(b *Backend, key string, expected string) error {
+	// TestLockID covers lockID's composition across the table/prefix/workspace
+// combinations TestBackendExtraPaths and TestBackendPrefixInWorkspace rely
+// on implicitly: two different workspaces must never land on the same
+// LockID, even when one workspace name is a substring of another or the
+// workspace key prefix is empty.
+func TestLockID(t *testing.T) {
+	cases := []struct {
+		name               string
+		tableName          string
+		workspaceKeyPrefix string
+		workspace          string
+		path               string
+	}{
+		{"default workspace, no prefix", "tf-state", "", "default", "test-env.tfstate"},
+		{"named workspace, no prefix", "tf-state", "", "s1", "test-env.tfstate"},
+		{"named workspace, with prefix", "tf-state", "env", "s2", "test-env.tfstate"},
+		{"workspace name is a prefix of another", "tf-state", "env", "env-1", "test-env.tfstate"},
+	}
+
+	seen := map[string]string{}
+	for _, tc := range cases {
+		c := &RemoteClient{
+			tableName:          tc.tableName,
+			workspaceKeyPrefix: tc.workspaceKeyPrefix,
+			workspace:          tc.workspace,
+			path:               tc.path,
+		}
+		id := c.lockID()
+
+		if other, ok := seen[id]; ok {
+			t.Fatalf("case %q and %q produced the same LockID %q", tc.name, other, id)
+		}
+		seen[id] = tc.name
+
+		// Same inputs must always produce the same LockID.
+		if again := c.lockID(); again != id {
+			t.Fatalf("case %q: lockID is not stable: got %q then %q", tc.name, id, again)
+		}
+	}
+}
+
+func TestKMSKeyIDForWorkspace(t *testing.T) {
+	kmsKeyIDs, err := validateKMSKeyIDConfig(map[string]interface{}{
+		"default": "arn:aws:kms:us-west-2:111122223333:key/default-key",
+		"prod":    "arn:aws:kms:us-west-2:111122223333:key/prod-key",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error validating kms_key_id map: %s", err)
+	}
+
+	cases := []struct {
+		workspace string
+		want      string
+	}{
+		{"default", "arn:aws:kms:us-west-2:111122223333:key/default-key"},
+		{"prod", "arn:aws:kms:us-west-2:111122223333:key/prod-key"},
+		{"staging", "arn:aws:kms:us-west-2:111122223333:key/default-key"},
+	}
+
+	for _, tc := range cases {
+		got, err := kmsKeyIDForWorkspace(kmsKeyIDs, tc.workspace)
+		if err != nil {
+			t.Fatalf("workspace %q: unexpected error: %s", tc.workspace, err)
+		}
+		if got != tc.want {
+			t.Fatalf("workspace %q: got key %q, want %q", tc.workspace, got, tc.want)
+		}
+	}
+}
+
+func TestValidateKMSKeyIDConfig_missingDefault(t *testing.T) {
+	_, err := validateKMSKeyIDConfig(map[string]interface{}{
+		"prod": "arn:aws:kms:us-west-2:111122223333:key/prod-key",
+	})
+	if err == nil {
+		t.Fatal("expected an error for a kms_key_id map with no \"default\" entry")
+	}
+}
+
+func testGetWorkspaceForKey(b *Backend, key string, expected string) error {
 		if actual := b.keyEnv(key); actual != expected {
 			return fmt.Errorf("incorrect workspace for key[%q]. Expected[%q]: Actual[%q]", key, expected, actual)
 		}
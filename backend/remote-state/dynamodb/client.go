@@ -0,0 +1,351 @@
+package dynamodb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// This file implements RemoteClient against the "StateID"/"SegmentID"
+// table shape createDynamoDBTable (in backend_test.go) sets up. The rest
+// of the backend this client normally sits inside of (backend.go's
+// schema/PrepareConfig, workspace key handling, the backend.Backend
+// plumbing the test file's `var _ backend.Backend = new(Backend)` and
+// `New()` calls expect) isn't part of this trimmed snapshot — there is
+// no `backend` package here at all — so RemoteClient is written here to
+// match exactly the shape backend_test.go already constructs it with
+// (dynClient/tableName/path/lockTable), independent of that missing
+// plumbing.
+
+// maxSegmentSize keeps each row comfortably under DynamoDB's 400 KB item
+// limit, leaving headroom for the other attributes on the item.
+const maxSegmentSize = 350 * 1024
+
+// metaSegmentID is the sentinel SegmentID value for the bookkeeping row
+// that records how many real segments exist, their combined digest, and
+// the write generation.
+const metaSegmentID = "meta"
+
+type RemoteClient struct {
+	dynClient *dynamodb.DynamoDB
+	tableName string
+	path      string
+	lockTable string
+
+	// workspace and workspaceKeyPrefix feed lockID's composition; both are
+	// zero-value ("") for a client built against the default workspace.
+	workspace          string
+	workspaceKeyPrefix string
+
+	// lockTableRangeKey is the lock table's RANGE key name, if the table
+	// was created with one (via the "lock_table_range_key" config). When
+	// set, Lock/Unlock/renewLease populate it with c.workspace so a table
+	// can hold one lock row per workspace instead of relying solely on
+	// lockID's composite string to avoid collisions.
+	lockTableRangeKey string
+
+	// sseCustomerKey and kmsKeyID are mutually exclusive (enforced by
+	// validateEncryptionConfig, in encryption.go); both nil/empty means
+	// state is stored as plaintext, same as before this field existed.
+	sseCustomerKey []byte
+	kmsKeyID       string
+	kmsClient      *kms.KMS
+
+	// lockHeartbeatInterval, heartbeatMu, and heartbeatStop back the
+	// TTL-backed lease renewal in lock.go; zero value for the interval
+	// means "use defaultLockHeartbeatInterval".
+	lockHeartbeatInterval time.Duration
+	heartbeatMu           sync.Mutex
+	heartbeatStop         chan struct{}
+}
+
+// segmentMeta is the "meta" row's shape: how many segments to expect,
+// the SHA-256 digest of the reassembled payload they should produce, a
+// monotonic generation used for optimistic concurrency on Put, and (only
+// when encryption is configured) the GCM nonce and, for the KMS path,
+// the wrapped per-object data key needed to decrypt them.
+type segmentMeta struct {
+	SegmentCount   int
+	Digest         string
+	Generation     int64
+	Nonce          []byte
+	WrappedDataKey []byte
+}
+
+func (c *RemoteClient) Get() ([]byte, error) {
+	items, err := c.queryAllSegments()
+	if err != nil {
+		return nil, fmt.Errorf("error querying state segments: %s", err)
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	var meta *segmentMeta
+	segments := map[int][]byte{}
+
+	for _, item := range items {
+		segmentID := aws.StringValue(item["SegmentID"].S)
+		if segmentID == metaSegmentID {
+			m, err := decodeSegmentMeta(item)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding state meta segment: %s", err)
+			}
+			meta = m
+			continue
+		}
+
+		idx, err := strconv.Atoi(segmentID)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected SegmentID %q", segmentID)
+		}
+		segments[idx] = item["Data"].B
+	}
+
+	if meta == nil || len(segments) != meta.SegmentCount {
+		// Either the meta row hasn't landed yet or some segment rows
+		// haven't: a write is straddling this read. Report "no state"
+		// rather than a corrupt one; the writer's next TransactWriteItems
+		// will make the state visible atomically.
+		return nil, nil
+	}
+
+	data := make([]byte, 0, meta.SegmentCount*maxSegmentSize)
+	for i := 0; i < meta.SegmentCount; i++ {
+		segment, ok := segments[i]
+		if !ok {
+			return nil, nil
+		}
+		data = append(data, segment...)
+	}
+
+	digest := sha256.Sum256(data)
+	if hex.EncodeToString(digest[:]) != meta.Digest {
+		return nil, fmt.Errorf("state for %q failed digest verification after reassembling %d segments", c.path, meta.SegmentCount)
+	}
+
+	return c.decrypt(&encryptedPayload{
+		Ciphertext:     data,
+		Nonce:          meta.Nonce,
+		WrappedDataKey: meta.WrappedDataKey,
+	})
+}
+
+func (c *RemoteClient) Put(data []byte) error {
+	prevCount := 0
+	var prevGeneration int64
+	havePrevMeta := false
+	if meta, err := c.currentMeta(); err == nil && meta != nil {
+		prevCount = meta.SegmentCount
+		prevGeneration = meta.Generation
+		havePrevMeta = true
+	}
+
+	payload, err := c.encrypt(data)
+	if err != nil {
+		return fmt.Errorf("error encrypting state: %s", err)
+	}
+
+	segments := chunkBytes(payload.Ciphertext, maxSegmentSize)
+	digest := sha256.Sum256(payload.Ciphertext)
+
+	meta := segmentMeta{
+		SegmentCount:   len(segments),
+		Digest:         hex.EncodeToString(digest[:]),
+		Generation:     time.Now().UnixNano(),
+		Nonce:          payload.Nonce,
+		WrappedDataKey: payload.WrappedDataKey,
+	}
+
+	items := make([]*dynamodb.TransactWriteItem, 0, len(segments)+1)
+	for i, segment := range segments {
+		items = append(items, &dynamodb.TransactWriteItem{
+			Put: &dynamodb.Put{
+				TableName: aws.String(c.tableName),
+				Item: map[string]*dynamodb.AttributeValue{
+					"StateID":   {S: aws.String(c.path)},
+					"SegmentID": {S: aws.String(fmt.Sprintf("%04d", i))},
+					"Data":      {B: segment},
+				},
+			},
+		})
+	}
+	metaItem := map[string]*dynamodb.AttributeValue{
+		"StateID":      {S: aws.String(c.path)},
+		"SegmentID":    {S: aws.String(metaSegmentID)},
+		"SegmentCount": {N: aws.String(strconv.Itoa(meta.SegmentCount))},
+		"Digest":       {S: aws.String(meta.Digest)},
+		"Generation":   {N: aws.String(strconv.FormatInt(meta.Generation, 10))},
+	}
+	if len(meta.Nonce) > 0 {
+		metaItem["Nonce"] = &dynamodb.AttributeValue{B: meta.Nonce}
+	}
+	if len(meta.WrappedDataKey) > 0 {
+		metaItem["WrappedDataKey"] = &dynamodb.AttributeValue{B: meta.WrappedDataKey}
+	}
+	// The condition below is the optimistic-concurrency check: it pins
+	// this write to the generation this Put actually read in currentMeta
+	// above, so a concurrent writer that read and wrote in between causes
+	// TransactWriteItems to fail the whole transaction instead of
+	// silently clobbering the other writer's segments.
+	metaPut := &dynamodb.Put{
+		TableName: aws.String(c.tableName),
+		Item:      metaItem,
+	}
+	if havePrevMeta {
+		metaPut.ConditionExpression = aws.String("Generation = :prevGeneration")
+		metaPut.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{
+			":prevGeneration": {N: aws.String(strconv.FormatInt(prevGeneration, 10))},
+		}
+	} else {
+		metaPut.ConditionExpression = aws.String("attribute_not_exists(StateID)")
+	}
+	items = append(items, &dynamodb.TransactWriteItem{Put: metaPut})
+
+	// A previous write with more segments than this one leaves orphaned
+	// rows past the new count; remove them in the same transaction so a
+	// reader never sees a mix of old and new segments.
+	for i := len(segments); i < prevCount; i++ {
+		items = append(items, &dynamodb.TransactWriteItem{
+			Delete: &dynamodb.Delete{
+				TableName: aws.String(c.tableName),
+				Key: map[string]*dynamodb.AttributeValue{
+					"StateID":   {S: aws.String(c.path)},
+					"SegmentID": {S: aws.String(fmt.Sprintf("%04d", i))},
+				},
+			},
+		})
+	}
+
+	_, err = c.dynClient.TransactWriteItems(&dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeTransactionCanceledException {
+			return fmt.Errorf("error writing state segments: the state was modified by another process since it was last read; re-run after refreshing state: %s", err)
+		}
+		return fmt.Errorf("error writing state segments: %s", err)
+	}
+
+	return nil
+}
+
+func (c *RemoteClient) Delete() error {
+	items, err := c.queryAllSegments()
+	if err != nil {
+		return fmt.Errorf("error querying state segments to delete: %s", err)
+	}
+
+	for _, item := range items {
+		_, err := c.dynClient.DeleteItem(&dynamodb.DeleteItemInput{
+			TableName: aws.String(c.tableName),
+			Key: map[string]*dynamodb.AttributeValue{
+				"StateID":   item["StateID"],
+				"SegmentID": item["SegmentID"],
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("error deleting state segment %q: %s", aws.StringValue(item["SegmentID"].S), err)
+		}
+	}
+
+	return nil
+}
+
+// queryAllSegments returns every row for c.path, following
+// LastEvaluatedKey across pages. DynamoDB Query caps a single response at
+// 1 MB, so a state spanning more than ~1 MB of segments would otherwise
+// come back as a partial page -- which Get's segment-count check would
+// then mistake for "no state" and Delete would leave the rest orphaned.
+func (c *RemoteClient) queryAllSegments() ([]map[string]*dynamodb.AttributeValue, error) {
+	var items []map[string]*dynamodb.AttributeValue
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(c.tableName),
+		KeyConditionExpression: aws.String("StateID = :id"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":id": {S: aws.String(c.path)},
+		},
+	}
+
+	err := c.dynClient.QueryPages(input, func(page *dynamodb.QueryOutput, lastPage bool) bool {
+		items = append(items, page.Items...)
+		return !lastPage
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// currentMeta fetches just the "meta" row for c.path, if any, so Put can
+// tell whether this write is shrinking the segment count.
+func (c *RemoteClient) currentMeta() (*segmentMeta, error) {
+	out, err := c.dynClient.GetItem(&dynamodb.GetItemInput{
+		TableName: aws.String(c.tableName),
+		Key: map[string]*dynamodb.AttributeValue{
+			"StateID":   {S: aws.String(c.path)},
+			"SegmentID": {S: aws.String(metaSegmentID)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+	return decodeSegmentMeta(out.Item)
+}
+
+func decodeSegmentMeta(item map[string]*dynamodb.AttributeValue) (*segmentMeta, error) {
+	count, err := strconv.Atoi(aws.StringValue(item["SegmentCount"].N))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SegmentCount: %s", err)
+	}
+	generation, err := strconv.ParseInt(aws.StringValue(item["Generation"].N), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Generation: %s", err)
+	}
+
+	m := &segmentMeta{
+		SegmentCount: count,
+		Digest:       aws.StringValue(item["Digest"].S),
+		Generation:   generation,
+	}
+	if v, ok := item["Nonce"]; ok {
+		m.Nonce = v.B
+	}
+	if v, ok := item["WrappedDataKey"]; ok {
+		m.WrappedDataKey = v.B
+	}
+	return m, nil
+}
+
+// chunkBytes splits data into segments no larger than size, preserving
+// order. An empty input produces a single empty segment, so even a
+// zero-length state round-trips through one segment rather than zero.
+func chunkBytes(data []byte, size int) [][]byte {
+	if len(data) == 0 {
+		return [][]byte{{}}
+	}
+
+	segments := make([][]byte, 0, (len(data)/size)+1)
+	for offset := 0; offset < len(data); offset += size {
+		end := offset + size
+		if end > len(data) {
+			end = len(data)
+		}
+		segments = append(segments, data[offset:end])
+	}
+	return segments
+}
+
@@ -0,0 +1,166 @@
+package dynamodb
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// defaultLockHeartbeatInterval is how often Lock refreshes ExpiresAt
+// while held, absent a "lock_heartbeat_interval" config override.
+const defaultLockHeartbeatInterval = 30 * time.Second
+
+// lockTTLMultiple sets the TTL relative to the heartbeat interval: a
+// holder that misses two heartbeats in a row is considered dead.
+const lockTTLMultiple = 2
+
+func (c *RemoteClient) heartbeatInterval() time.Duration {
+	if c.lockHeartbeatInterval > 0 {
+		return c.lockHeartbeatInterval
+	}
+	return defaultLockHeartbeatInterval
+}
+
+// lockID composes the lock row's partition key as
+// {tableName}/{workspaceKeyPrefix}/{workspace}/{hash}, so two workspaces
+// whose c.path happens to collide (or whose names are substrings of one
+// another) still land on distinct LockID values. The hash is of c.path
+// itself, which is what actually identifies the state being locked.
+func (c *RemoteClient) lockID() string {
+	h := sha256.Sum256([]byte(c.path))
+	return fmt.Sprintf("%s/%s/%s/%x", c.tableName, c.workspaceKeyPrefix, c.workspace, h[:8])
+}
+
+// lockKey builds the primary key for the lock row: just LockID, unless
+// lockTableRangeKey is set, in which case the table has a RANGE key too
+// and it's populated with c.workspace so the table can be queried or
+// scanned per-workspace.
+func (c *RemoteClient) lockKey() map[string]*dynamodb.AttributeValue {
+	key := map[string]*dynamodb.AttributeValue{
+		"LockID": {S: aws.String(c.lockID())},
+	}
+	if c.lockTableRangeKey != "" {
+		key[c.lockTableRangeKey] = &dynamodb.AttributeValue{S: aws.String(c.workspace)}
+	}
+	return key
+}
+
+// Lock acquires the lock row for c.path (see lockID), reaping it first if
+// it's expired (ExpiresAt in the past, left behind by a holder that
+// crashed without calling Unlock), then starts a background heartbeat
+// that refreshes ExpiresAt until Unlock is called.
+func (c *RemoteClient) Lock(id string) error {
+	now := time.Now()
+	expiresAt := now.Add(c.heartbeatInterval() * lockTTLMultiple)
+
+	item := c.lockKey()
+	item["Info"] = &dynamodb.AttributeValue{S: aws.String(id)}
+	item["ExpiresAt"] = &dynamodb.AttributeValue{N: aws.String(fmt.Sprintf("%d", expiresAt.Unix()))}
+
+	_, err := c.dynClient.PutItem(&dynamodb.PutItemInput{
+		TableName: aws.String(c.lockTable),
+		Item:      item,
+		// Either there's no existing row, or there is one but its
+		// ExpiresAt has already passed: treat that as abandoned and
+		// reap it. A row with a live ExpiresAt fails the condition and
+		// the Lock call reports the conflict, same as before TTL existed.
+		ConditionExpression: aws.String("attribute_not_exists(LockID) OR ExpiresAt < :now"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now": {N: aws.String(fmt.Sprintf("%d", now.Unix()))},
+		},
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return fmt.Errorf("state %q is already locked", c.path)
+		}
+		return fmt.Errorf("error acquiring state lock: %s", err)
+	}
+
+	c.startHeartbeat(id)
+	return nil
+}
+
+func (c *RemoteClient) Unlock(id string) error {
+	c.stopHeartbeat()
+
+	_, err := c.dynClient.DeleteItem(&dynamodb.DeleteItemInput{
+		TableName:           aws.String(c.lockTable),
+		Key:                 c.lockKey(),
+		ConditionExpression: aws.String("Info = :id"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":id": {S: aws.String(id)},
+		},
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			return fmt.Errorf("state %q is locked by someone else", c.path)
+		}
+		return fmt.Errorf("error releasing state lock: %s", err)
+	}
+
+	return nil
+}
+
+// startHeartbeat refreshes ExpiresAt every heartbeatInterval until
+// stopHeartbeat is called. It's a best-effort background loop: if a
+// single refresh fails (a transient network blip), it tries again at the
+// next tick rather than giving up, since the goal is only to keep
+// ExpiresAt comfortably ahead of "now" for as long as the process is
+// alive to do so.
+func (c *RemoteClient) startHeartbeat(id string) {
+	c.heartbeatMu.Lock()
+	defer c.heartbeatMu.Unlock()
+
+	stop := make(chan struct{})
+	c.heartbeatStop = stop
+
+	go func() {
+		ticker := time.NewTicker(c.heartbeatInterval())
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.renewLease(id)
+			}
+		}
+	}()
+}
+
+func (c *RemoteClient) stopHeartbeat() {
+	c.heartbeatMu.Lock()
+	defer c.heartbeatMu.Unlock()
+
+	if c.heartbeatStop != nil {
+		close(c.heartbeatStop)
+		c.heartbeatStop = nil
+	}
+}
+
+func (c *RemoteClient) renewLease(id string) {
+	expiresAt := time.Now().Add(c.heartbeatInterval() * lockTTLMultiple)
+
+	_, err := c.dynClient.UpdateItem(&dynamodb.UpdateItemInput{
+		TableName:           aws.String(c.lockTable),
+		Key:                 c.lockKey(),
+		UpdateExpression:    aws.String("SET ExpiresAt = :expiresAt"),
+		ConditionExpression: aws.String("Info = :id"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":expiresAt": {N: aws.String(fmt.Sprintf("%d", expiresAt.Unix()))},
+			":id":        {S: aws.String(id)},
+		},
+	})
+	if err != nil {
+		// Nothing to do but try again next tick; a lost lock is
+		// discovered when the holder's Unlock fails its Info check, not
+		// here.
+		return
+	}
+}
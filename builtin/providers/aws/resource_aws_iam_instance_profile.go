@@ -15,6 +15,12 @@ func resourceAwsIamInstanceProfile() *schema.Resource {
 		Read:   resourceAwsIamInstanceProfileRead,
 		Update: resourceAwsIamInstanceProfileUpdate,
 		Delete: resourceAwsIamInstanceProfileDelete,
+		// ImportStatePassthrough just seeds the ID; core calls Read right
+		// after, which already pulls name, path, and the full roles set
+		// from GetInstanceProfile, so no custom importer func is needed.
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
 
 		Schema: map[string]*schema.Schema{
 			"arn": &schema.Schema{
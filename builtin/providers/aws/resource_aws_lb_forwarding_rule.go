@@ -0,0 +1,293 @@
+package aws
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourceAwsLbForwardingRule wires an NLB listener to a target pool and,
+// unlike a typical listener resource, also owns registering/deregistering
+// the pool's ENI members. AWS's RegisterTargets/DeregisterTargets APIs are
+// scoped to a target group ARN rather than to a listener, but draining a
+// member out of rotation is only safe once nothing is forwarding traffic
+// to it, which is a property of the rule, not the pool - so membership
+// lives here.
+func resourceAwsLbForwardingRule() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAwsLbForwardingRuleCreate,
+		Read:   resourceAwsLbForwardingRuleRead,
+		Update: resourceAwsLbForwardingRuleUpdate,
+		Delete: resourceAwsLbForwardingRuleDelete,
+
+		Schema: map[string]*schema.Schema{
+			"load_balancer_arn": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"target_group_arn": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"port": &schema.Schema{
+				Type:     schema.TypeInt,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"protocol": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"network_interface_ids": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+
+			"arn": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceAwsLbForwardingRuleCreate(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).elbv2conn
+
+	targetGroupArn := d.Get("target_group_arn").(string)
+	resp, err := conn.CreateListener(&elbv2.CreateListenerInput{
+		LoadBalancerArn: aws.String(d.Get("load_balancer_arn").(string)),
+		Port:            aws.Int64(int64(d.Get("port").(int))),
+		Protocol:        aws.String(d.Get("protocol").(string)),
+		DefaultActions: []*elbv2.Action{
+			{
+				Type:           aws.String(elbv2.ActionTypeEnumForward),
+				TargetGroupArn: aws.String(targetGroupArn),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("Error creating NLB forwarding rule: %s", err)
+	}
+
+	d.SetId(*resp.Listeners[0].ListenerArn)
+
+	if err := lbForwardingRuleSetMembers(d, meta); err != nil {
+		return err
+	}
+
+	return resourceAwsLbForwardingRuleRead(d, meta)
+}
+
+func resourceAwsLbForwardingRuleUpdate(d *schema.ResourceData, meta interface{}) error {
+	if d.HasChange("network_interface_ids") {
+		if err := lbForwardingRuleSetMembers(d, meta); err != nil {
+			return err
+		}
+	}
+
+	return resourceAwsLbForwardingRuleRead(d, meta)
+}
+
+// lbForwardingRuleSetMembers reconciles the rule's target group membership
+// with network_interface_ids, the same add/remove-on-a-set-difference
+// pattern instanceProfileSetRoles uses for IAM instance profile roles:
+// track the currently-applied set in local state as each individual
+// register/deregister call succeeds, so a failure partway through leaves
+// d's state matching what AWS actually has rather than what was requested.
+func lbForwardingRuleSetMembers(d *schema.ResourceData, meta interface{}) error {
+	ec2conn := meta.(*AWSClient).ec2conn
+	elbconn := meta.(*AWSClient).elbv2conn
+	targetGroupArn := d.Get("target_group_arn").(string)
+
+	oldInterface, newInterface := d.GetChange("network_interface_ids")
+	oldMembers := oldInterface.(*schema.Set)
+	newMembers := newInterface.(*schema.Set)
+
+	currentMembers := schema.CopySet(oldMembers)
+
+	d.Partial(true)
+
+	for _, member := range oldMembers.Difference(newMembers).List() {
+		eniId := member.(string)
+		target, err := lbTargetForNetworkInterface(ec2conn, eniId)
+		if err != nil {
+			return fmt.Errorf("Error resolving ENI %s for deregistration: %s", eniId, err)
+		}
+
+		_, err = elbconn.DeregisterTargets(&elbv2.DeregisterTargetsInput{
+			TargetGroupArn: aws.String(targetGroupArn),
+			Targets:        []*elbv2.TargetDescription{target},
+		})
+		if err != nil {
+			return fmt.Errorf("Error deregistering ENI %s from target group %s: %s", eniId, targetGroupArn, err)
+		}
+		currentMembers.Remove(member)
+		d.Set("network_interface_ids", currentMembers)
+		d.SetPartial("network_interface_ids")
+	}
+
+	for _, member := range newMembers.Difference(oldMembers).List() {
+		eniId := member.(string)
+		target, err := lbTargetForNetworkInterface(ec2conn, eniId)
+		if err != nil {
+			return fmt.Errorf("Error resolving ENI %s for registration: %s", eniId, err)
+		}
+
+		_, err = elbconn.RegisterTargets(&elbv2.RegisterTargetsInput{
+			TargetGroupArn: aws.String(targetGroupArn),
+			Targets:        []*elbv2.TargetDescription{target},
+		})
+		if err != nil {
+			return fmt.Errorf("Error registering ENI %s with target group %s: %s", eniId, targetGroupArn, err)
+		}
+		currentMembers.Add(member)
+		d.Set("network_interface_ids", currentMembers)
+		d.SetPartial("network_interface_ids")
+	}
+
+	d.Partial(false)
+
+	if newMembers.Len() == 0 {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Waiting for target group %s members to become healthy", targetGroupArn)
+	stateConf := &resource.StateChangeConf{
+		Pending:    []string{"false"},
+		Target:     []string{"true"},
+		Refresh:    lbTargetGroupHealthyRefreshFunc(elbconn, targetGroupArn),
+		Timeout:    5 * time.Minute,
+		Delay:      10 * time.Second,
+		MinTimeout: 5 * time.Second,
+	}
+
+	_, err := stateConf.WaitForState()
+	if err != nil {
+		return fmt.Errorf("Error waiting for target group %s members to become healthy: %s", targetGroupArn, err)
+	}
+
+	return nil
+}
+
+// lbTargetForNetworkInterface resolves an ENI to the TargetDescription the
+// elbv2 API expects: NLB "ip" targets are addressed by the ENI's primary
+// private IP, not its ID.
+func lbTargetForNetworkInterface(ec2conn *ec2.EC2, eniId string) (*elbv2.TargetDescription, error) {
+	resp, err := ec2conn.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{
+		NetworkInterfaceIds: []*string{aws.String(eniId)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.NetworkInterfaces) == 0 {
+		return nil, fmt.Errorf("network interface %s not found", eniId)
+	}
+
+	return &elbv2.TargetDescription{
+		Id: resp.NetworkInterfaces[0].PrivateIpAddress,
+	}, nil
+}
+
+// lbTargetGroupHealthyRefreshFunc reports "true" once every currently
+// registered target in targetGroupArn has health state "healthy", mirroring
+// networkInterfaceAttachmentRefreshFunc's boolean-string convention.
+func lbTargetGroupHealthyRefreshFunc(conn *elbv2.ELBV2, targetGroupArn string) resource.StateRefreshFunc {
+	return func() (interface{}, string, error) {
+		resp, err := conn.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{
+			TargetGroupArn: aws.String(targetGroupArn),
+		})
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, description := range resp.TargetHealthDescriptions {
+			if description.TargetHealth == nil || aws.StringValue(description.TargetHealth.State) != elbv2.TargetHealthStateEnumHealthy {
+				return resp, "false", nil
+			}
+		}
+
+		return resp, "true", nil
+	}
+}
+
+func resourceAwsLbForwardingRuleRead(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).elbv2conn
+
+	resp, err := conn.DescribeListeners(&elbv2.DescribeListenersInput{
+		ListenerArns: []*string{aws.String(d.Id())},
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "ListenerNotFound" {
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("Error reading NLB forwarding rule %s: %s", d.Id(), err)
+	}
+	if len(resp.Listeners) == 0 {
+		d.SetId("")
+		return nil
+	}
+
+	listener := resp.Listeners[0]
+	d.Set("load_balancer_arn", listener.LoadBalancerArn)
+	d.Set("port", listener.Port)
+	d.Set("protocol", listener.Protocol)
+	d.Set("arn", listener.ListenerArn)
+	if len(listener.DefaultActions) > 0 {
+		d.Set("target_group_arn", listener.DefaultActions[0].TargetGroupArn)
+	}
+
+	return nil
+}
+
+func resourceAwsLbForwardingRuleDelete(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).elbv2conn
+
+	targetGroupArn := d.Get("target_group_arn").(string)
+	for _, member := range d.Get("network_interface_ids").(*schema.Set).List() {
+		eniId := member.(string)
+		target, err := lbTargetForNetworkInterface(meta.(*AWSClient).ec2conn, eniId)
+		if err != nil {
+			log.Printf("[WARN] Could not resolve ENI %s to deregister on delete: %s", eniId, err)
+			continue
+		}
+		_, err = conn.DeregisterTargets(&elbv2.DeregisterTargetsInput{
+			TargetGroupArn: aws.String(targetGroupArn),
+			Targets:        []*elbv2.TargetDescription{target},
+		})
+		if err != nil {
+			log.Printf("[WARN] Error deregistering ENI %s on delete: %s", eniId, err)
+		}
+	}
+
+	_, err := conn.DeleteListener(&elbv2.DeleteListenerInput{
+		ListenerArn: aws.String(d.Id()),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "ListenerNotFound" {
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("Error deleting NLB forwarding rule %s: %s", d.Id(), err)
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,140 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourceAwsLbHealthCheck applies a health check configuration to an
+// existing NLB target group, kept as its own resource (rather than inlined
+// on resource_aws_lb_target_pool.go's schema) so health check settings can
+// be managed without caring how the pool itself was created. The forwarding
+// rule's health waiter does not read these settings; it uses its own fixed
+// timeouts.
+func resourceAwsLbHealthCheck() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAwsLbHealthCheckCreate,
+		Read:   resourceAwsLbHealthCheckRead,
+		Update: resourceAwsLbHealthCheckCreate,
+		Delete: resourceAwsLbHealthCheckDelete,
+
+		Schema: map[string]*schema.Schema{
+			"target_group_arn": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"protocol": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "TCP",
+			},
+
+			"port": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "traffic-port",
+			},
+
+			"path": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+
+			"interval": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  30,
+			},
+
+			"timeout": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  10,
+			},
+
+			"healthy_threshold": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  3,
+			},
+
+			"unhealthy_threshold": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  3,
+			},
+		},
+	}
+}
+
+func resourceAwsLbHealthCheckCreate(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).elbv2conn
+
+	targetGroupArn := d.Get("target_group_arn").(string)
+	input := &elbv2.ModifyTargetGroupInput{
+		TargetGroupArn:             aws.String(targetGroupArn),
+		HealthCheckProtocol:        aws.String(d.Get("protocol").(string)),
+		HealthCheckPort:            aws.String(d.Get("port").(string)),
+		HealthCheckIntervalSeconds: aws.Int64(int64(d.Get("interval").(int))),
+		HealthCheckTimeoutSeconds:  aws.Int64(int64(d.Get("timeout").(int))),
+		HealthyThresholdCount:      aws.Int64(int64(d.Get("healthy_threshold").(int))),
+		UnhealthyThresholdCount:    aws.Int64(int64(d.Get("unhealthy_threshold").(int))),
+	}
+	if path := d.Get("path").(string); path != "" {
+		input.HealthCheckPath = aws.String(path)
+	}
+
+	_, err := conn.ModifyTargetGroup(input)
+	if err != nil {
+		return fmt.Errorf("Error configuring health check for NLB target group %s: %s", targetGroupArn, err)
+	}
+
+	d.SetId(targetGroupArn)
+	return resourceAwsLbHealthCheckRead(d, meta)
+}
+
+func resourceAwsLbHealthCheckRead(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).elbv2conn
+
+	resp, err := conn.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{
+		TargetGroupArns: []*string{aws.String(d.Id())},
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "TargetGroupNotFound" {
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("Error reading health check for NLB target group %s: %s", d.Id(), err)
+	}
+	if len(resp.TargetGroups) == 0 {
+		d.SetId("")
+		return nil
+	}
+
+	group := resp.TargetGroups[0]
+	d.Set("target_group_arn", group.TargetGroupArn)
+	d.Set("protocol", group.HealthCheckProtocol)
+	d.Set("port", group.HealthCheckPort)
+	d.Set("path", group.HealthCheckPath)
+	d.Set("interval", group.HealthCheckIntervalSeconds)
+	d.Set("timeout", group.HealthCheckTimeoutSeconds)
+	d.Set("healthy_threshold", group.HealthyThresholdCount)
+	d.Set("unhealthy_threshold", group.UnhealthyThresholdCount)
+
+	return nil
+}
+
+func resourceAwsLbHealthCheckDelete(d *schema.ResourceData, meta interface{}) error {
+	// Health check settings live on the target group itself; there's
+	// nothing to delete independently of it, so this just stops tracking
+	// the resource and leaves the group's health check settings in place,
+	// unchanged, for resourceAwsLbTargetPoolDelete to remove along with it.
+	d.SetId("")
+	return nil
+}
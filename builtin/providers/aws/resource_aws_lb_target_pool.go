@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourceAwsLbTargetPool manages the NLB target group that a set of
+// ENI-backed targets is registered against. Registering/deregistering the
+// ENIs themselves is owned by resourceAwsLbForwardingRule, not this
+// resource - see its doc comment for why.
+func resourceAwsLbTargetPool() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAwsLbTargetPoolCreate,
+		Read:   resourceAwsLbTargetPoolRead,
+		Delete: resourceAwsLbTargetPoolDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"vpc_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"port": &schema.Schema{
+				Type:     schema.TypeInt,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"protocol": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"arn": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceAwsLbTargetPoolCreate(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).elbv2conn
+
+	name := d.Get("name").(string)
+	resp, err := conn.CreateTargetGroup(&elbv2.CreateTargetGroupInput{
+		Name:       aws.String(name),
+		VpcId:      aws.String(d.Get("vpc_id").(string)),
+		Port:       aws.Int64(int64(d.Get("port").(int))),
+		Protocol:   aws.String(d.Get("protocol").(string)),
+		TargetType: aws.String(elbv2.TargetTypeEnumIp),
+	})
+	if err != nil {
+		return fmt.Errorf("Error creating NLB target group %s: %s", name, err)
+	}
+
+	d.SetId(*resp.TargetGroups[0].TargetGroupArn)
+	return resourceAwsLbTargetPoolRead(d, meta)
+}
+
+func resourceAwsLbTargetPoolRead(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).elbv2conn
+
+	resp, err := conn.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{
+		TargetGroupArns: []*string{aws.String(d.Id())},
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "TargetGroupNotFound" {
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("Error reading NLB target group %s: %s", d.Id(), err)
+	}
+	if len(resp.TargetGroups) == 0 {
+		d.SetId("")
+		return nil
+	}
+
+	group := resp.TargetGroups[0]
+	d.Set("name", group.TargetGroupName)
+	d.Set("vpc_id", group.VpcId)
+	d.Set("port", group.Port)
+	d.Set("protocol", group.Protocol)
+	d.Set("arn", group.TargetGroupArn)
+
+	return nil
+}
+
+func resourceAwsLbTargetPoolDelete(d *schema.ResourceData, meta interface{}) error {
+	conn := meta.(*AWSClient).elbv2conn
+
+	_, err := conn.DeleteTargetGroup(&elbv2.DeleteTargetGroupInput{
+		TargetGroupArn: aws.String(d.Id()),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "TargetGroupNotFound" {
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("Error deleting NLB target group %s: %s", d.Id(), err)
+	}
+
+	d.SetId("")
+	return nil
+}
@@ -161,14 +161,14 @@ func resourceAwsOpsworksRdsDbInstanceRegister(d *schema.ResourceData, meta inter
 	}
 
 	err := resource.Retry(2*time.Minute, func() *resource.RetryError {
-		var cerr error
-		_, cerr = client.RegisterRdsDbInstance(req)
+		_, cerr := client.RegisterRdsDbInstance(req)
 		if cerr != nil {
-			log.Printf("[INFO] client error")
 			if opserr, ok := cerr.(awserr.Error); ok {
-				// XXX: handle errors
+				if isOpsworksRdsDbInstanceRetryableError(opserr) {
+					log.Printf("[DEBUG] retryable OpsWorks error registering rds db instance: %s: %s", opserr.Code(), opserr.Message())
+					return resource.RetryableError(cerr)
+				}
 				log.Printf("[ERROR] OpsWorks error: %s message: %s", opserr.Code(), opserr.Message())
-				return resource.RetryableError(cerr)
 			}
 			return resource.NonRetryableError(cerr)
 		}
@@ -181,3 +181,16 @@ func resourceAwsOpsworksRdsDbInstanceRegister(d *schema.ResourceData, meta inter
 
 	return resourceAwsOpsworksRdsDbInstanceRead(d, meta)
 }
+
+// isOpsworksRdsDbInstanceRetryableError reports whether err represents a
+// transient condition worth retrying (throttling, or an eventual-consistency
+// "not found yet" on a just-created resource) as opposed to a validation
+// failure that will never succeed no matter how many times it's retried.
+func isOpsworksRdsDbInstanceRetryableError(err awserr.Error) bool {
+	switch err.Code() {
+	case "Throttling", "ThrottlingException", "RequestLimitExceeded", "ResourceNotFoundException":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,258 @@
+package aws
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/opsworks"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// opsworksRdsDbInstancesBatchConcurrency bounds how many RegisterRdsDbInstance
+// calls resourceAwsOpsworksRdsDbInstancesCreate/Update run at once, so
+// registering a large set of databases doesn't open one goroutine per
+// instance against the OpsWorks API.
+const opsworksRdsDbInstancesBatchConcurrency = 10
+
+// resourceAwsOpsworksRdsDbInstances is the plural counterpart to
+// aws_opsworks_rds_db_instance: it registers a whole list of RDS instances
+// against a stack in one resource, so Read can reconcile the set with a
+// single DescribeRdsDbInstances call per stack instead of one per instance.
+func resourceAwsOpsworksRdsDbInstances() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAwsOpsworksRdsDbInstancesCreate,
+		Read:   resourceAwsOpsworksRdsDbInstancesRead,
+		Update: resourceAwsOpsworksRdsDbInstancesUpdate,
+		Delete: resourceAwsOpsworksRdsDbInstancesDelete,
+
+		Schema: map[string]*schema.Schema{
+			"stack_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"instance": {
+				Type:     schema.TypeList,
+				Required: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"arn": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"db_user": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"db_password": {
+							Type:      schema.TypeString,
+							Required:  true,
+							Sensitive: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+type opsworksRdsDbInstanceBatchEntry struct {
+	arn      string
+	dbUser   string
+	password string
+}
+
+func expandOpsworksRdsDbInstancesBatch(raw []interface{}) []opsworksRdsDbInstanceBatchEntry {
+	entries := make([]opsworksRdsDbInstanceBatchEntry, len(raw))
+	for i, v := range raw {
+		m := v.(map[string]interface{})
+		entries[i] = opsworksRdsDbInstanceBatchEntry{
+			arn:      m["arn"].(string),
+			dbUser:   m["db_user"].(string),
+			password: m["db_password"].(string),
+		}
+	}
+	return entries
+}
+
+// opsworksRdsDbInstancesRunBatch fans work out across at most
+// opsworksRdsDbInstancesBatchConcurrency goroutines and returns the first
+// error encountered, following the same intent as resource.Retry: callers
+// get one combined error rather than having to aggregate per-entry results
+// themselves.
+func opsworksRdsDbInstancesRunBatch(entries []opsworksRdsDbInstanceBatchEntry, work func(opsworksRdsDbInstanceBatchEntry) error) error {
+	sem := make(chan struct{}, opsworksRdsDbInstancesBatchConcurrency)
+	errCh := make(chan error, len(entries))
+
+	var wg sync.WaitGroup
+	for _, entry := range entries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(entry opsworksRdsDbInstanceBatchEntry) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errCh <- work(entry)
+		}(entry)
+	}
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func opsworksRdsDbInstanceRegisterWithBackoff(client *opsworks.OpsWorks, stackID string, entry opsworksRdsDbInstanceBatchEntry) error {
+	req := &opsworks.RegisterRdsDbInstanceInput{
+		StackId:          aws.String(stackID),
+		RdsDbInstanceArn: aws.String(entry.arn),
+		DbUser:           aws.String(entry.dbUser),
+		DbPassword:       aws.String(entry.password),
+	}
+
+	return resource.Retry(5*time.Minute, func() *resource.RetryError {
+		_, err := client.RegisterRdsDbInstance(req)
+		if err != nil {
+			if opserr, ok := err.(awserr.Error); ok && isOpsworksRdsDbInstanceRetryableError(opserr) {
+				log.Printf("[DEBUG] retryable OpsWorks error registering rds db instance %s: %s: %s", entry.arn, opserr.Code(), opserr.Message())
+				return resource.RetryableError(err)
+			}
+			return resource.NonRetryableError(err)
+		}
+		return nil
+	})
+}
+
+func resourceAwsOpsworksRdsDbInstancesCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*AWSClient).opsworksconn
+	stackID := d.Get("stack_id").(string)
+
+	entries := expandOpsworksRdsDbInstancesBatch(d.Get("instance").([]interface{}))
+	if err := opsworksRdsDbInstancesRunBatch(entries, func(entry opsworksRdsDbInstanceBatchEntry) error {
+		return opsworksRdsDbInstanceRegisterWithBackoff(client, stackID, entry)
+	}); err != nil {
+		return err
+	}
+
+	d.SetId(stackID)
+
+	return resourceAwsOpsworksRdsDbInstancesRead(d, meta)
+}
+
+func resourceAwsOpsworksRdsDbInstancesRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*AWSClient).opsworksconn
+	stackID := d.Get("stack_id").(string)
+
+	// A single DescribeRdsDbInstances call reconciles every instance in
+	// this resource, rather than the per-instance call the singular
+	// aws_opsworks_rds_db_instance resource issues.
+	resp, err := client.DescribeRdsDbInstances(&opsworks.DescribeRdsDbInstancesInput{
+		StackId: aws.String(stackID),
+	})
+	if err != nil {
+		return err
+	}
+
+	registered := make(map[string]*opsworks.RdsDbInstance, len(resp.RdsDbInstances))
+	for _, instance := range resp.RdsDbInstances {
+		registered[aws.StringValue(instance.RdsDbInstanceArn)] = instance
+	}
+
+	var instances []map[string]interface{}
+	for _, raw := range d.Get("instance").([]interface{}) {
+		m := raw.(map[string]interface{})
+		instance, ok := registered[m["arn"].(string)]
+		if !ok {
+			continue
+		}
+		instances = append(instances, map[string]interface{}{
+			"arn":         aws.StringValue(instance.RdsDbInstanceArn),
+			"db_user":     aws.StringValue(instance.DbUser),
+			"db_password": m["db_password"],
+		})
+	}
+	d.Set("instance", instances)
+
+	if len(instances) == 0 {
+		d.SetId("")
+	}
+
+	return nil
+}
+
+func resourceAwsOpsworksRdsDbInstancesUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*AWSClient).opsworksconn
+	stackID := d.Get("stack_id").(string)
+
+	o, n := d.GetChange("instance")
+	oldByArn := make(map[string]opsworksRdsDbInstanceBatchEntry)
+	for _, entry := range expandOpsworksRdsDbInstancesBatch(o.([]interface{})) {
+		oldByArn[entry.arn] = entry
+	}
+
+	newEntries := expandOpsworksRdsDbInstancesBatch(n.([]interface{}))
+
+	var toRegister, toUpdate []opsworksRdsDbInstanceBatchEntry
+	for _, entry := range newEntries {
+		old, known := oldByArn[entry.arn]
+		switch {
+		case !known:
+			toRegister = append(toRegister, entry)
+		case old.dbUser != entry.dbUser || old.password != entry.password:
+			toUpdate = append(toUpdate, entry)
+		}
+	}
+
+	if err := opsworksRdsDbInstancesRunBatch(toRegister, func(entry opsworksRdsDbInstanceBatchEntry) error {
+		return opsworksRdsDbInstanceRegisterWithBackoff(client, stackID, entry)
+	}); err != nil {
+		return err
+	}
+
+	if err := opsworksRdsDbInstancesRunBatch(toUpdate, func(entry opsworksRdsDbInstanceBatchEntry) error {
+		return resource.Retry(5*time.Minute, func() *resource.RetryError {
+			_, err := client.UpdateRdsDbInstance(&opsworks.UpdateRdsDbInstanceInput{
+				RdsDbInstanceArn: aws.String(entry.arn),
+				DbUser:           aws.String(entry.dbUser),
+				DbPassword:       aws.String(entry.password),
+			})
+			if err != nil {
+				if opserr, ok := err.(awserr.Error); ok && isOpsworksRdsDbInstanceRetryableError(opserr) {
+					return resource.RetryableError(err)
+				}
+				return resource.NonRetryableError(err)
+			}
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+
+	return resourceAwsOpsworksRdsDbInstancesRead(d, meta)
+}
+
+func resourceAwsOpsworksRdsDbInstancesDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*AWSClient).opsworksconn
+	entries := expandOpsworksRdsDbInstancesBatch(d.Get("instance").([]interface{}))
+
+	return opsworksRdsDbInstancesRunBatch(entries, func(entry opsworksRdsDbInstanceBatchEntry) error {
+		_, err := client.DeregisterRdsDbInstance(&opsworks.DeregisterRdsDbInstanceInput{
+			RdsDbInstanceArn: aws.String(entry.arn),
+		})
+		if err != nil {
+			if opserr, ok := err.(awserr.Error); ok && opserr.Code() == "ResourceNotFoundException" {
+				return nil
+			}
+			return fmt.Errorf("error deregistering rds db instance %s: %s", entry.arn, err)
+		}
+		return nil
+	})
+}
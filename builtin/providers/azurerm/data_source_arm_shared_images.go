@@ -0,0 +1,78 @@
+package azurerm
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// dataSourceArmSharedImages enumerates the published versions of an
+// `azurerm_shared_image_definition` within an `azurerm_shared_image_gallery`,
+// mirroring the gallery's "list image versions" API.
+func dataSourceArmSharedImages() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceArmSharedImagesRead,
+
+		Schema: map[string]*schema.Schema{
+			"gallery_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"image_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"resource_group_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"versions": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"managed_image_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"location": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceArmSharedImagesRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*ArmClient)
+
+	galleryName := d.Get("gallery_name").(string)
+	imageName := d.Get("image_name").(string)
+	resourceGroup := d.Get("resource_group_name").(string)
+
+	resp, err := client.galleryImageVersionsClient.ListByGalleryImage(resourceGroup, galleryName, imageName)
+	if err != nil {
+		return fmt.Errorf("Error listing Shared Image Versions (Image %q / Gallery %q / resource group %q): %s", imageName, galleryName, resourceGroup, err)
+	}
+
+	versions := make([]interface{}, 0, len(resp.Values))
+	for _, version := range resp.Values {
+		versions = append(versions, map[string]interface{}{
+			"name":             version.Name,
+			"managed_image_id": version.ManagedImageID,
+			"location":         version.Location,
+		})
+	}
+
+	d.Set("versions", versions)
+	d.SetId(fmt.Sprintf("%s/%s/%s", resourceGroup, galleryName, imageName))
+
+	return nil
+}
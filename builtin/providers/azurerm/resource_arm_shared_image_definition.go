@@ -0,0 +1,173 @@
+package azurerm
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourceArmSharedImageDefinition manages a definition (the "what", e.g.
+// "ubuntu-18-04") within an `azurerm_shared_image_gallery`. Versions of
+// this definition (`azurerm_shared_image_version`) are what actually
+// carry a replicated managed image.
+func resourceArmSharedImageDefinition() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceArmSharedImageDefinitionCreate,
+		Read:   resourceArmSharedImageDefinitionRead,
+		Update: resourceArmSharedImageDefinitionCreate,
+		Delete: resourceArmSharedImageDefinitionDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"gallery_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"resource_group_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"location": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"os_type": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"identifier": {
+				Type:     schema.TypeList,
+				Required: true,
+				ForceNew: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"publisher": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+						"offer": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+						"sku": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+					},
+				},
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"tags": tagsSchema(),
+		},
+	}
+}
+
+func resourceArmSharedImageDefinitionCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*ArmClient)
+
+	name := d.Get("name").(string)
+	galleryName := d.Get("gallery_name").(string)
+	resourceGroup := d.Get("resource_group_name").(string)
+
+	identifiers := d.Get("identifier").([]interface{})
+	identifier := identifiers[0].(map[string]interface{})
+
+	image := galleryImageCreateOrUpdateParameters{
+		Name:          name,
+		GalleryName:   galleryName,
+		ResourceGroup: resourceGroup,
+		Location:      d.Get("location").(string),
+		OsType:        d.Get("os_type").(string),
+		Description:   d.Get("description").(string),
+		Identifier: galleryImageIdentifier{
+			Publisher: identifier["publisher"].(string),
+			Offer:     identifier["offer"].(string),
+			Sku:       identifier["sku"].(string),
+		},
+		Tags: expandTags(d.Get("tags").(map[string]interface{})),
+	}
+
+	if _, err := client.galleryImagesClient.CreateOrUpdate(resourceGroup, galleryName, name, image); err != nil {
+		return fmt.Errorf("Error creating Shared Image Definition %q (Gallery %q / resource group %q): %s", name, galleryName, resourceGroup, err)
+	}
+
+	resp, err := client.galleryImagesClient.Get(resourceGroup, galleryName, name)
+	if err != nil {
+		return fmt.Errorf("Error retrieving Shared Image Definition %q (Gallery %q / resource group %q): %s", name, galleryName, resourceGroup, err)
+	}
+
+	d.SetId(resp.ID)
+
+	return resourceArmSharedImageDefinitionRead(d, meta)
+}
+
+func resourceArmSharedImageDefinitionRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*ArmClient)
+
+	id, err := parseAzureResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	resourceGroup := id.ResourceGroup
+	galleryName := id.Path["galleries"]
+	name := id.Path["images"]
+
+	resp, err := client.galleryImagesClient.Get(resourceGroup, galleryName, name)
+	if err != nil {
+		if resp.StatusCode == 404 {
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("Error making Read request on Shared Image Definition %q (Gallery %q / resource group %q): %s", name, galleryName, resourceGroup, err)
+	}
+
+	d.Set("name", resp.Name)
+	d.Set("gallery_name", galleryName)
+	d.Set("resource_group_name", resourceGroup)
+	d.Set("location", resp.Location)
+	d.Set("os_type", resp.OsType)
+	d.Set("description", resp.Description)
+	d.Set("identifier", []interface{}{
+		map[string]interface{}{
+			"publisher": resp.Identifier.Publisher,
+			"offer":     resp.Identifier.Offer,
+			"sku":       resp.Identifier.Sku,
+		},
+	})
+	flattenAndSetTags(d, resp.Tags)
+
+	return nil
+}
+
+func resourceArmSharedImageDefinitionDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*ArmClient)
+
+	id, err := parseAzureResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	resourceGroup := id.ResourceGroup
+	galleryName := id.Path["galleries"]
+	name := id.Path["images"]
+
+	if _, err := client.galleryImagesClient.Delete(resourceGroup, galleryName, name); err != nil {
+		return fmt.Errorf("Error deleting Shared Image Definition %q (Gallery %q / resource group %q): %s", name, galleryName, resourceGroup, err)
+	}
+
+	return nil
+}
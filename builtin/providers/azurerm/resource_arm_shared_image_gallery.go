@@ -0,0 +1,135 @@
+package azurerm
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourceArmSharedImageGallery manages an Azure Shared Image Gallery, the
+// container resource that `azurerm_shared_image_definition` and
+// `azurerm_shared_image_version` (resource_arm_shared_image_definition.go,
+// resource_arm_shared_image_version.go) publish images into.
+//
+// This package in its current state has no `ArmClient` struct and no
+// `provider.go`/`config.go` wiring up the `gallery`/`galleryimage`/
+// `galleryimageversion` SDK clients (only resource_arm_image_test.go,
+// which exercises code that doesn't exist in this checkout), so these
+// resources are written the way the rest of azurerm already is --
+// Create/Read/Update/Delete over a `meta.(*ArmClient)` client field,
+// following the same shape the test file's `imageClient`/`vmClient`/
+// `diskClient` usage implies -- without that scaffolding actually
+// existing here to compile against.
+func resourceArmSharedImageGallery() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceArmSharedImageGalleryCreate,
+		Read:   resourceArmSharedImageGalleryRead,
+		Update: resourceArmSharedImageGalleryUpdate,
+		Delete: resourceArmSharedImageGalleryDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"resource_group_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"location": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"unique_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"tags": tagsSchema(),
+		},
+	}
+}
+
+func resourceArmSharedImageGalleryCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*ArmClient)
+
+	name := d.Get("name").(string)
+	resourceGroup := d.Get("resource_group_name").(string)
+
+	gallery := galleryCreateOrUpdateParameters{
+		Name:          name,
+		ResourceGroup: resourceGroup,
+		Location:      d.Get("location").(string),
+		Description:   d.Get("description").(string),
+		Tags:          expandTags(d.Get("tags").(map[string]interface{})),
+	}
+
+	if _, err := client.galleryClient.CreateOrUpdate(resourceGroup, name, gallery); err != nil {
+		return fmt.Errorf("Error creating Shared Image Gallery %q (resource group %q): %s", name, resourceGroup, err)
+	}
+
+	resp, err := client.galleryClient.Get(resourceGroup, name)
+	if err != nil {
+		return fmt.Errorf("Error retrieving Shared Image Gallery %q (resource group %q): %s", name, resourceGroup, err)
+	}
+
+	d.SetId(resp.ID)
+
+	return resourceArmSharedImageGalleryRead(d, meta)
+}
+
+func resourceArmSharedImageGalleryRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*ArmClient)
+
+	id, err := parseAzureResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	resourceGroup := id.ResourceGroup
+	name := id.Path["galleries"]
+
+	resp, err := client.galleryClient.Get(resourceGroup, name)
+	if err != nil {
+		if resp.StatusCode == 404 {
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("Error making Read request on Shared Image Gallery %q (resource group %q): %s", name, resourceGroup, err)
+	}
+
+	d.Set("name", resp.Name)
+	d.Set("resource_group_name", resourceGroup)
+	d.Set("location", resp.Location)
+	d.Set("description", resp.Description)
+	d.Set("unique_name", resp.UniqueName)
+	flattenAndSetTags(d, resp.Tags)
+
+	return nil
+}
+
+func resourceArmSharedImageGalleryUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceArmSharedImageGalleryCreate(d, meta)
+}
+
+func resourceArmSharedImageGalleryDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*ArmClient)
+
+	id, err := parseAzureResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	resourceGroup := id.ResourceGroup
+	name := id.Path["galleries"]
+
+	if _, err := client.galleryClient.Delete(resourceGroup, name); err != nil {
+		return fmt.Errorf("Error deleting Shared Image Gallery %q (resource group %q): %s", name, resourceGroup, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,320 @@
+package azurerm
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccAzureRMSharedImageGallery_basic(t *testing.T) {
+	ri := acctest.RandInt()
+	config := fmt.Sprintf(testAccAzureRMSharedImageGallery_basic, ri, ri)
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMSharedImageGalleryDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMSharedImageGalleryExists("azurerm_shared_image_gallery.test"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMSharedImageDefinition_basic(t *testing.T) {
+	ri := acctest.RandInt()
+	config := fmt.Sprintf(testAccAzureRMSharedImageDefinition_basic, ri, ri, ri)
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMSharedImageDefinitionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMSharedImageDefinitionExists("azurerm_shared_image_definition.test"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccAzureRMSharedImageVersion_basic(t *testing.T) {
+	ri := acctest.RandInt()
+	config := fmt.Sprintf(testAccAzureRMSharedImageVersion_basic, ri, ri, ri, ri)
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testCheckAzureRMSharedImageVersionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					testCheckAzureRMSharedImageVersionExists("azurerm_shared_image_version.test"),
+					resource.TestCheckResourceAttr(
+						"data.azurerm_shared_images.test", "versions.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func testCheckAzureRMSharedImageGalleryExists(name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+
+		galleryName := rs.Primary.Attributes["name"]
+		resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+		conn := testAccProvider.Meta().(*ArmClient).galleryClient
+
+		resp, err := conn.Get(resourceGroup, galleryName)
+		if err != nil {
+			return fmt.Errorf("Bad: Get on galleryClient: %s", err)
+		}
+
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("Bad: Shared Image Gallery %q (resource group %q) does not exist", galleryName, resourceGroup)
+		}
+
+		return nil
+	}
+}
+
+func testCheckAzureRMSharedImageGalleryDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*ArmClient).galleryClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "azurerm_shared_image_gallery" {
+			continue
+		}
+
+		galleryName := rs.Primary.Attributes["name"]
+		resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+		resp, err := conn.Get(resourceGroup, galleryName)
+		if err != nil {
+			return nil
+		}
+
+		if resp.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("Shared Image Gallery still exists: %q", galleryName)
+		}
+	}
+
+	return nil
+}
+
+func testCheckAzureRMSharedImageDefinitionExists(name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+
+		imageName := rs.Primary.Attributes["name"]
+		galleryName := rs.Primary.Attributes["gallery_name"]
+		resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+		conn := testAccProvider.Meta().(*ArmClient).galleryImagesClient
+
+		resp, err := conn.Get(resourceGroup, galleryName, imageName)
+		if err != nil {
+			return fmt.Errorf("Bad: Get on galleryImagesClient: %s", err)
+		}
+
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("Bad: Shared Image Definition %q (Gallery %q / resource group %q) does not exist", imageName, galleryName, resourceGroup)
+		}
+
+		return nil
+	}
+}
+
+func testCheckAzureRMSharedImageDefinitionDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*ArmClient).galleryImagesClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "azurerm_shared_image_definition" {
+			continue
+		}
+
+		imageName := rs.Primary.Attributes["name"]
+		galleryName := rs.Primary.Attributes["gallery_name"]
+		resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+		resp, err := conn.Get(resourceGroup, galleryName, imageName)
+		if err != nil {
+			return nil
+		}
+
+		if resp.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("Shared Image Definition still exists: %q", imageName)
+		}
+	}
+
+	return nil
+}
+
+func testCheckAzureRMSharedImageVersionExists(name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Not found: %s", name)
+		}
+
+		versionName := rs.Primary.Attributes["name"]
+		imageName := rs.Primary.Attributes["image_name"]
+		galleryName := rs.Primary.Attributes["gallery_name"]
+		resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+		conn := testAccProvider.Meta().(*ArmClient).galleryImageVersionsClient
+
+		resp, err := conn.Get(resourceGroup, galleryName, imageName, versionName)
+		if err != nil {
+			return fmt.Errorf("Bad: Get on galleryImageVersionsClient: %s", err)
+		}
+
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("Bad: Shared Image Version %q (Image %q / Gallery %q / resource group %q) does not exist", versionName, imageName, galleryName, resourceGroup)
+		}
+
+		return nil
+	}
+}
+
+func testCheckAzureRMSharedImageVersionDestroy(s *terraform.State) error {
+	conn := testAccProvider.Meta().(*ArmClient).galleryImageVersionsClient
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "azurerm_shared_image_version" {
+			continue
+		}
+
+		versionName := rs.Primary.Attributes["name"]
+		imageName := rs.Primary.Attributes["image_name"]
+		galleryName := rs.Primary.Attributes["gallery_name"]
+		resourceGroup := rs.Primary.Attributes["resource_group_name"]
+
+		resp, err := conn.Get(resourceGroup, galleryName, imageName, versionName)
+		if err != nil {
+			return nil
+		}
+
+		if resp.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("Shared Image Version still exists: %q", versionName)
+		}
+	}
+
+	return nil
+}
+
+var testAccAzureRMSharedImageGallery_basic = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestRG-%d"
+    location = "West Central US"
+}
+
+resource "azurerm_shared_image_gallery" "test" {
+    name = "acctestsig%d"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "${azurerm_resource_group.test.location}"
+    description = "Shared images acceptance test gallery"
+}
+`
+
+var testAccAzureRMSharedImageDefinition_basic = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestRG-%d"
+    location = "West Central US"
+}
+
+resource "azurerm_shared_image_gallery" "test" {
+    name = "acctestsig%d"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "${azurerm_resource_group.test.location}"
+}
+
+resource "azurerm_shared_image_definition" "test" {
+    name = "acctestsid%d"
+    gallery_name = "${azurerm_shared_image_gallery.test.name}"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "${azurerm_resource_group.test.location}"
+    os_type = "Linux"
+
+    identifier {
+        publisher = "AccTestPublisher"
+        offer = "AccTestOffer"
+        sku = "AccTestSku"
+    }
+}
+`
+
+var testAccAzureRMSharedImageVersion_basic = `
+resource "azurerm_resource_group" "test" {
+    name = "acctestRG-%d"
+    location = "West Central US"
+}
+
+resource "azurerm_image" "test" {
+    name = "acctestimg%d"
+    location = "${azurerm_resource_group.test.location}"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    os_disk_os_type = "Linux"
+    os_disk_os_state = "Generalized"
+    os_disk_blob_uri = "https://terraformdev.blob.core.windows.net/packerimages/ubuntu_plain.vhd"
+    os_disk_size_gb = 30
+    os_disk_caching = "None"
+}
+
+resource "azurerm_shared_image_gallery" "test" {
+    name = "acctestsig%d"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "${azurerm_resource_group.test.location}"
+}
+
+resource "azurerm_shared_image_definition" "test" {
+    name = "acctestsid%d"
+    gallery_name = "${azurerm_shared_image_gallery.test.name}"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "${azurerm_resource_group.test.location}"
+    os_type = "Linux"
+
+    identifier {
+        publisher = "AccTestPublisher"
+        offer = "AccTestOffer"
+        sku = "AccTestSku"
+    }
+}
+
+resource "azurerm_shared_image_version" "test" {
+    name = "1.0.0"
+    image_name = "${azurerm_shared_image_definition.test.name}"
+    gallery_name = "${azurerm_shared_image_gallery.test.name}"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    location = "${azurerm_resource_group.test.location}"
+    managed_image_id = "${azurerm_image.test.id}"
+
+    target_region {
+        name = "West Central US"
+        regional_replica_count = 1
+    }
+}
+
+data "azurerm_shared_images" "test" {
+    gallery_name = "${azurerm_shared_image_gallery.test.name}"
+    image_name = "${azurerm_shared_image_definition.test.name}"
+    resource_group_name = "${azurerm_resource_group.test.name}"
+    depends_on = ["azurerm_shared_image_version.test"]
+}
+`
@@ -0,0 +1,201 @@
+package azurerm
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourceArmSharedImageVersion manages a single replicated version (e.g.
+// "1.0.0") of an `azurerm_shared_image_definition`, publishing a managed
+// image (`managed_image_id`, an `azurerm_image.id`) into one or more target
+// regions with a per-region replica count and storage account type. A
+// virtual machine cannot be given as the source; only a managed image ID
+// is accepted.
+func resourceArmSharedImageVersion() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceArmSharedImageVersionCreate,
+		Read:   resourceArmSharedImageVersionRead,
+		Update: resourceArmSharedImageVersionCreate,
+		Delete: resourceArmSharedImageVersionDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"image_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"gallery_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"resource_group_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"location": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"managed_image_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"exclude_from_latest": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"target_region": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"regional_replica_count": {
+							Type:     schema.TypeInt,
+							Required: true,
+						},
+						"storage_account_type": {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "Standard_LRS",
+						},
+					},
+				},
+			},
+			"tags": tagsSchema(),
+		},
+	}
+}
+
+func expandSharedImageVersionTargetRegions(d *schema.ResourceData) []targetRegion {
+	regionsRaw := d.Get("target_region").([]interface{})
+	regions := make([]targetRegion, 0, len(regionsRaw))
+
+	for _, regionRaw := range regionsRaw {
+		region := regionRaw.(map[string]interface{})
+
+		regions = append(regions, targetRegion{
+			Name:                 region["name"].(string),
+			RegionalReplicaCount: region["regional_replica_count"].(int),
+			StorageAccountType:   region["storage_account_type"].(string),
+		})
+	}
+
+	return regions
+}
+
+func flattenSharedImageVersionTargetRegions(regions []targetRegion) []interface{} {
+	result := make([]interface{}, 0, len(regions))
+
+	for _, region := range regions {
+		result = append(result, map[string]interface{}{
+			"name":                   region.Name,
+			"regional_replica_count": region.RegionalReplicaCount,
+			"storage_account_type":   region.StorageAccountType,
+		})
+	}
+
+	return result
+}
+
+func resourceArmSharedImageVersionCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*ArmClient)
+
+	name := d.Get("name").(string)
+	imageName := d.Get("image_name").(string)
+	galleryName := d.Get("gallery_name").(string)
+	resourceGroup := d.Get("resource_group_name").(string)
+
+	version := galleryImageVersionCreateOrUpdateParameters{
+		Name:              name,
+		ImageName:         imageName,
+		GalleryName:       galleryName,
+		ResourceGroup:     resourceGroup,
+		Location:          d.Get("location").(string),
+		ManagedImageID:    d.Get("managed_image_id").(string),
+		ExcludeFromLatest: d.Get("exclude_from_latest").(bool),
+		TargetRegions:     expandSharedImageVersionTargetRegions(d),
+		Tags:              expandTags(d.Get("tags").(map[string]interface{})),
+	}
+
+	if _, err := client.galleryImageVersionsClient.CreateOrUpdate(resourceGroup, galleryName, imageName, name, version); err != nil {
+		return fmt.Errorf("Error creating Shared Image Version %q (Image %q / Gallery %q / resource group %q): %s", name, imageName, galleryName, resourceGroup, err)
+	}
+
+	resp, err := client.galleryImageVersionsClient.Get(resourceGroup, galleryName, imageName, name)
+	if err != nil {
+		return fmt.Errorf("Error retrieving Shared Image Version %q (Image %q / Gallery %q / resource group %q): %s", name, imageName, galleryName, resourceGroup, err)
+	}
+
+	d.SetId(resp.ID)
+
+	return resourceArmSharedImageVersionRead(d, meta)
+}
+
+func resourceArmSharedImageVersionRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*ArmClient)
+
+	id, err := parseAzureResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	resourceGroup := id.ResourceGroup
+	galleryName := id.Path["galleries"]
+	imageName := id.Path["images"]
+	name := id.Path["versions"]
+
+	resp, err := client.galleryImageVersionsClient.Get(resourceGroup, galleryName, imageName, name)
+	if err != nil {
+		if resp.StatusCode == 404 {
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("Error making Read request on Shared Image Version %q (Image %q / Gallery %q / resource group %q): %s", name, imageName, galleryName, resourceGroup, err)
+	}
+
+	d.Set("name", resp.Name)
+	d.Set("image_name", imageName)
+	d.Set("gallery_name", galleryName)
+	d.Set("resource_group_name", resourceGroup)
+	d.Set("location", resp.Location)
+	d.Set("managed_image_id", resp.ManagedImageID)
+	d.Set("exclude_from_latest", resp.ExcludeFromLatest)
+	d.Set("target_region", flattenSharedImageVersionTargetRegions(resp.TargetRegions))
+	flattenAndSetTags(d, resp.Tags)
+
+	return nil
+}
+
+func resourceArmSharedImageVersionDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*ArmClient)
+
+	id, err := parseAzureResourceID(d.Id())
+	if err != nil {
+		return err
+	}
+	resourceGroup := id.ResourceGroup
+	galleryName := id.Path["galleries"]
+	imageName := id.Path["images"]
+	name := id.Path["versions"]
+
+	if _, err := client.galleryImageVersionsClient.Delete(resourceGroup, galleryName, imageName, name); err != nil {
+		return fmt.Errorf("Error deleting Shared Image Version %q (Image %q / Gallery %q / resource group %q): %s", name, imageName, galleryName, resourceGroup, err)
+	}
+
+	return nil
+}
@@ -6,11 +6,36 @@ import (
 	"github.com/hashicorp/terraform/helper/schema"
 )
 
+// SchemaReader abstracts the read half of _ConfigReader so that check/rule
+// flatten logic can be exercised in table-driven tests against a plain
+// map[string]interface{} (via _MapConfigReader) without spinning up a real
+// *schema.ResourceData.
+type SchemaReader interface {
+	BackingType() string
+	Context() *_ProviderContext
+
+	GetBool(attrName _SchemaAttr) bool
+	GetBoolOK(attrName _SchemaAttr) (b, ok bool)
+	GetDurationOK(attrName _SchemaAttr) (time.Duration, bool)
+	GetFloat64OK(attrName _SchemaAttr) (float64, bool)
+	GetIntOK(attrName _SchemaAttr) (int, bool)
+	GetListOK(attrName _SchemaAttr) (_InterfaceList, bool)
+	GetMap(attrName _SchemaAttr) _InterfaceMap
+	GetSetAsListOK(attrName _SchemaAttr) (_InterfaceList, bool)
+	GetString(attrName _SchemaAttr) string
+	GetStringOK(attrName _SchemaAttr) (string, bool)
+	GetStringPtr(attrName _SchemaAttr) *string
+	GetStringSlice(attrName _SchemaAttr) []string
+	GetTags(attrName _SchemaAttr) _Tags
+}
+
 type _ConfigReader struct {
 	ctxt *_ProviderContext
 	d    *schema.ResourceData
 }
 
+var _ SchemaReader = (*_ConfigReader)(nil)
+
 func _NewConfigReader(ctxt *_ProviderContext, d *schema.ResourceData) *_ConfigReader {
 	return &_ConfigReader{
 		ctxt: ctxt,
@@ -0,0 +1,181 @@
+package circonus
+
+import (
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// _MapConfigReader is the other half of the SchemaReader split: it satisfies
+// the same interface as _ConfigReader but is backed by a plain
+// map[string]interface{} instead of a *schema.ResourceData, so check/rule
+// flatten logic can be exercised with table-driven tests that never touch
+// the Terraform schema machinery.
+type _MapConfigReader struct {
+	ctxt *_ProviderContext
+	m    map[string]interface{}
+}
+
+var _ SchemaReader = (*_MapConfigReader)(nil)
+
+func _NewMapConfigReader(ctxt *_ProviderContext, m map[string]interface{}) *_MapConfigReader {
+	return &_MapConfigReader{
+		ctxt: ctxt,
+		m:    m,
+	}
+}
+
+func (r *_MapConfigReader) BackingType() string {
+	return "map"
+}
+
+func (r *_MapConfigReader) Context() *_ProviderContext {
+	return r.ctxt
+}
+
+func (r *_MapConfigReader) GetBool(attrName _SchemaAttr) bool {
+	if v, ok := r.GetBoolOK(attrName); ok {
+		return v
+	}
+
+	return false
+}
+
+func (r *_MapConfigReader) GetBoolOK(attrName _SchemaAttr) (b, ok bool) {
+	v, found := r.m[string(attrName)]
+	if !found || v == nil {
+		return false, false
+	}
+
+	return v.(bool), true
+}
+
+func (r *_MapConfigReader) GetDurationOK(attrName _SchemaAttr) (time.Duration, bool) {
+	v, found := r.m[string(attrName)]
+	if !found || v == nil {
+		return time.Duration(0), false
+	}
+
+	d, err := time.ParseDuration(v.(string))
+	if err != nil {
+		return time.Duration(0), false
+	}
+
+	return d, true
+}
+
+func (r *_MapConfigReader) GetFloat64OK(attrName _SchemaAttr) (float64, bool) {
+	v, found := r.m[string(attrName)]
+	if !found || v == nil {
+		return 0.0, false
+	}
+
+	return v.(float64), true
+}
+
+func (r *_MapConfigReader) GetIntOK(attrName _SchemaAttr) (int, bool) {
+	v, found := r.m[string(attrName)]
+	if !found || v == nil {
+		return 0, false
+	}
+
+	return v.(int), true
+}
+
+func (r *_MapConfigReader) GetListOK(attrName _SchemaAttr) (_InterfaceList, bool) {
+	v, found := r.m[string(attrName)]
+	if !found || v == nil {
+		return nil, false
+	}
+
+	return _InterfaceList{v.([]interface{})}, true
+}
+
+func (r *_MapConfigReader) GetMap(attrName _SchemaAttr) _InterfaceMap {
+	v, found := r.m[string(attrName)]
+	if !found || v == nil {
+		return nil
+	}
+
+	src := v.(map[string]interface{})
+	m := make(map[string]interface{}, len(src))
+	for k, v := range src {
+		m[k] = v
+	}
+
+	return _InterfaceMap(m)
+}
+
+func (r *_MapConfigReader) GetSetAsListOK(attrName _SchemaAttr) (_InterfaceList, bool) {
+	v, found := r.m[string(attrName)]
+	if !found || v == nil {
+		return nil, false
+	}
+
+	switch set := v.(type) {
+	case *schema.Set:
+		return set.List(), true
+	case []interface{}:
+		return set, true
+	default:
+		return nil, false
+	}
+}
+
+func (r *_MapConfigReader) GetString(attrName _SchemaAttr) string {
+	if s, ok := r.GetStringOK(attrName); ok {
+		return s
+	}
+
+	return ""
+}
+
+func (r *_MapConfigReader) GetStringOK(attrName _SchemaAttr) (string, bool) {
+	v, found := r.m[string(attrName)]
+	if !found || v == nil {
+		return "", false
+	}
+
+	return v.(string), true
+}
+
+func (r *_MapConfigReader) GetStringPtr(attrName _SchemaAttr) *string {
+	v, found := r.m[string(attrName)]
+	if !found || v == nil {
+		return nil
+	}
+
+	switch s := v.(type) {
+	case string:
+		return &s
+	case *string:
+		return s
+	}
+
+	return nil
+}
+
+func (r *_MapConfigReader) GetStringSlice(attrName _SchemaAttr) []string {
+	v, found := r.m[string(attrName)]
+	if !found || v == nil {
+		return nil
+	}
+
+	return v.([]string)
+}
+
+func (r *_MapConfigReader) GetTags(attrName _SchemaAttr) _Tags {
+	v, found := r.m[string(attrName)]
+	if !found || v == nil {
+		return injectTag(r.ctxt, _Tags{})
+	}
+
+	switch tags := v.(type) {
+	case *schema.Set:
+		return injectTagPtr(r.ctxt, flattenSet(tags))
+	case []*string:
+		return injectTagPtr(r.ctxt, tags)
+	default:
+		return injectTag(r.ctxt, _Tags{})
+	}
+}
@@ -0,0 +1,161 @@
+package circonus
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/circonus-labs/circonus-gometrics/api/config"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+var contactGroupCIDRegexp = regexp.MustCompile("^" + config.ContactGroupPrefix + "/(" + config.DefaultCIDRegex + ")$")
+
+// resolveContactGroupCID resolves ref -- which may be a bare numeric contact
+// group ID ("1234"), a full CID ("/contact_group/1234"), or a contact group
+// name -- to its canonical CID. Name lookups call the Circonus API's
+// /contact_group list endpoint through ctxt and cache the name -> CID
+// mapping for the lifetime of ctxt, so a config referencing the same group
+// from several places (rule set overlays, check notifications, maintenance
+// windows) costs a single round trip rather than one per reference.
+//
+// This only covers the lookup itself. Wiring it into every TypeInt contact
+// group attribute via a StateFunc/DiffSuppressFunc pair (below) is left
+// unattached: this package in this tree has no resource_circonus_rule_set.go,
+// resource_circonus_check.go, or resource_circonus_contact_group.go for
+// those attributes to live on, only attr_reader_config.go,
+// attr_reader_map.go, attr_writer_config.go, and utils.go.
+func resolveContactGroupCID(ctxt *_ProviderContext, ref string) (string, error) {
+	if ref == "" {
+		return "", nil
+	}
+
+	if cid, ok := parseContactGroupCID(ref); ok {
+		return cid, nil
+	}
+
+	cache, err := contactGroupCacheFor(ctxt)
+	if err != nil {
+		return "", err
+	}
+
+	cid, ok := cache.lookup(ref)
+	if !ok {
+		return "", fmt.Errorf("no contact_group found with ID, CID, or name %q", ref)
+	}
+
+	return cid, nil
+}
+
+// parseContactGroupCID recognizes ref as either a full contact_group CID or
+// a bare numeric ID, returning the canonical CID form in either case. It
+// returns false for anything else, including contact group names, which
+// require the cached API lookup in resolveContactGroupCID.
+func parseContactGroupCID(ref string) (string, bool) {
+	if contactGroupCIDRegexp.MatchString(ref) {
+		return ref, true
+	}
+
+	if id, err := strconv.Atoi(ref); err == nil {
+		return failoverGroupIDToCID(id), true
+	}
+
+	return "", false
+}
+
+// contactGroupCache memoizes the name -> CID mapping fetched from a single
+// provider context's /contact_group listing.
+type contactGroupCache struct {
+	mu     sync.Mutex
+	byName map[string]string
+	loaded bool
+}
+
+var (
+	contactGroupCachesMu sync.Mutex
+	contactGroupCaches   = map[*_ProviderContext]*contactGroupCache{}
+)
+
+func contactGroupCacheFor(ctxt *_ProviderContext) (*contactGroupCache, error) {
+	contactGroupCachesMu.Lock()
+	cache, ok := contactGroupCaches[ctxt]
+	if !ok {
+		cache = &contactGroupCache{}
+		contactGroupCaches[ctxt] = cache
+	}
+	contactGroupCachesMu.Unlock()
+
+	if err := cache.ensureLoaded(ctxt); err != nil {
+		return nil, err
+	}
+
+	return cache, nil
+}
+
+func (c *contactGroupCache) ensureLoaded(ctxt *_ProviderContext) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.loaded {
+		return nil
+	}
+
+	groups, err := ctxt.client.FetchContactGroups()
+	if err != nil {
+		return fmt.Errorf("unable to list contact groups: %s", err)
+	}
+
+	byName := make(map[string]string, len(*groups))
+	for _, g := range *groups {
+		byName[g.Name] = string(g.CID)
+	}
+
+	c.byName = byName
+	c.loaded = true
+
+	return nil
+}
+
+func (c *contactGroupCache) lookup(name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cid, ok := c.byName[name]
+	return cid, ok
+}
+
+// contactGroupStateFunc normalizes whatever form a user writes in
+// config -- numeric ID, CID, or name -- down to the canonical CID stored in
+// state, so drift detection compares CIDs rather than whatever spelling the
+// config happened to use.
+func contactGroupStateFunc(ctxt *_ProviderContext) schema.SchemaStateFunc {
+	return func(v interface{}) string {
+		cid, err := resolveContactGroupCID(ctxt, v.(string))
+		if err != nil {
+			return v.(string)
+		}
+
+		return cid
+	}
+}
+
+// contactGroupDiffSuppressFunc suppresses a diff when old and new resolve to
+// the same contact group CID, so a config already pinned to a numeric ID
+// doesn't show a perpetual diff against a name-based or CID-based value
+// normalized into state by contactGroupStateFunc.
+func contactGroupDiffSuppressFunc(ctxt *_ProviderContext) schema.SchemaDiffSuppressFunc {
+	return func(k, old, new string, d *schema.ResourceData) bool {
+		oldCID, err := resolveContactGroupCID(ctxt, old)
+		if err != nil {
+			return false
+		}
+
+		newCID, err := resolveContactGroupCID(ctxt, new)
+		if err != nil {
+			return false
+		}
+
+		return oldCID == newCID
+	}
+}
@@ -75,7 +75,7 @@ func flattenSet(s *schema.Set) []*string {
 }
 
 // listToSet returns a TypeSet from the given list.
-func stringListToSet(stringList []string, keyName schemaAttr) []interface{} {
+func stringListToSet(stringList []string, keyName _SchemaAttr) []interface{} {
 	m := make([]interface{}, 0, len(stringList))
 	for _, v := range stringList {
 		s := make(map[string]interface{}, 1)
@@ -100,45 +100,31 @@ func normalizeTimeDurationStringToSeconds(v interface{}) string {
 	}
 }
 
-// configGetBool returns the boolean value if found.
-func configGetBool(d *schema.ResourceData, attrName schemaAttr) bool {
-	return d.Get(string(attrName)).(bool)
+// configGetBool returns the boolean value if found. It accepts any
+// SchemaReader so callers can exercise this logic against a _MapConfigReader
+// in tests instead of a real *schema.ResourceData.
+func configGetBool(r SchemaReader, attrName _SchemaAttr) bool {
+	return r.GetBool(attrName)
 }
 
 // configGetBoolOk returns the boolean value if found and true as the second
 // argument, otherwise returns false if the value was not found.
-func configGetBoolOK(d *schema.ResourceData, attrName schemaAttr) (b, found bool) {
-	if v, ok := d.GetOk(string(attrName)); ok {
-		return v.(bool), true
-	}
-
-	return false, false
+func configGetBoolOK(r SchemaReader, attrName _SchemaAttr) (b, found bool) {
+	return r.GetBoolOK(attrName)
 }
 
-func configGetDurationOK(d *schema.ResourceData, attrName schemaAttr) (time.Duration, bool) {
-	if v, ok := d.GetOk(string(attrName)); ok {
-		d, err := time.ParseDuration(v.(string))
-		if err != nil {
-			return time.Duration(0), false
-		}
-
-		return d, true
-	}
-
-	return time.Duration(0), false
+func configGetDurationOK(r SchemaReader, attrName _SchemaAttr) (time.Duration, bool) {
+	return r.GetDurationOK(attrName)
 }
 
-func schemaGetSetAsListOk(d *schema.ResourceData, attrName schemaAttr) (interfaceList, bool) {
-	if listRaw, ok := d.GetOk(string(attrName)); ok {
-		return listRaw.(*schema.Set).List(), true
-	}
-	return nil, false
+func schemaGetSetAsListOk(r SchemaReader, attrName _SchemaAttr) (_InterfaceList, bool) {
+	return r.GetSetAsListOK(attrName)
 }
 
 // configGetString returns an attribute as a string.  If the attribute is not
 // found, return an empty string.
-func configGetString(d *schema.ResourceData, attrName schemaAttr) string {
-	if s, ok := schemaGetStringOK(d, attrName); ok {
+func configGetString(r SchemaReader, attrName _SchemaAttr) string {
+	if s, ok := schemaGetStringOK(r, attrName); ok {
 		return s
 	}
 
@@ -147,22 +133,14 @@ func configGetString(d *schema.ResourceData, attrName schemaAttr) string {
 
 // schemaGetStringOK returns an attribute as a string and true if the attribute
 // was found.  If the attribute is not found, return an empty string.
-func schemaGetStringOK(d *schema.ResourceData, attrName schemaAttr) (string, bool) {
-	if v, ok := d.GetOk(string(attrName)); ok {
-		return v.(string), ok
-	}
-
-	return "", false
+func schemaGetStringOK(r SchemaReader, attrName _SchemaAttr) (string, bool) {
+	return r.GetStringOK(attrName)
 }
 
 // configGetStringPtr returns an attribute as a *string.  If the attribute is
 // not found, return a nil pointer.
-func configGetStringPtr(d *schema.ResourceData, attrName schemaAttr) *string {
-	if s, ok := schemaGetStringOK(d, attrName); ok {
-		return &s
-	}
-
-	return nil
+func configGetStringPtr(r SchemaReader, attrName _SchemaAttr) *string {
+	return r.GetStringPtr(attrName)
 }
 
 func indirect(v interface{}) interface{} {
@@ -1,9 +1,11 @@
 package dns
 
 import (
-	"github.com/hashicorp/terraform/helper/schema"
 	"net"
 	"sort"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/miekg/dns"
 )
 
 func dataSourceDnsARecord() *schema.Resource {
@@ -14,6 +16,20 @@ func dataSourceDnsARecord() *schema.Resource {
 				Type:     schema.TypeString,
 				Required: true,
 			},
+
+			// resolver points the lookup at an explicit resolver instead of
+			// the stdlib host resolver; unset, behavior is unchanged.
+			"resolver": resolverSchema(),
+
+			// dnssec requests a DO-bit response and refuses to populate
+			// addrs unless the reply came back authenticated. Only takes
+			// effect when "resolver" is also set, since net.LookupIP has no
+			// way to ask for or see DNSSEC state.
+			"dnssec": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
 			// Optionally sort A records in alphabetical order.
 			// This is helpful when a name uses round-robin DNS, which may
 			// sort records with multiple addresses in a non-deterministic order.
@@ -46,23 +62,46 @@ func dataSourceDnsARecord() *schema.Resource {
 
 func dataSourceDnsARecordRead(d *schema.ResourceData, meta interface{}) error {
 	host := d.Get("host").(string)
-
-	records, err := net.LookupIP(host)
-	if err != nil {
-		return err
-	}
-
-	addrs := make([]string, 0)
 	ipv4only := d.Get("ipv4").(bool)
 	sortingEnabled := d.Get("sort").(bool)
 
-	for _, ip := range records {
-		if ipv4only {
-			if ipv4 := ip.To4(); ipv4 != nil {
-				addrs = append(addrs, ipv4.String())
+	var addrs []string
+
+	if rc := expandResolverConfig(d); rc != nil {
+		answers, err := lookupWithResolver(rc, host, dns.TypeA)
+		if err != nil {
+			return err
+		}
+
+		addrs = make([]string, 0)
+		for _, rr := range answers {
+			a, ok := rr.(*dns.A)
+			if !ok {
+				continue
+			}
+			if ipv4only {
+				if ipv4 := a.A.To4(); ipv4 != nil {
+					addrs = append(addrs, ipv4.String())
+				}
+			} else {
+				addrs = append(addrs, a.A.String())
+			}
+		}
+	} else {
+		records, err := net.LookupIP(host)
+		if err != nil {
+			return err
+		}
+
+		addrs = make([]string, 0)
+		for _, ip := range records {
+			if ipv4only {
+				if ipv4 := ip.To4(); ipv4 != nil {
+					addrs = append(addrs, ipv4.String())
+				}
+			} else {
+				addrs = append(addrs, ip.String())
 			}
-		} else {
-			addrs = append(addrs, ip.String())
 		}
 	}
 
@@ -0,0 +1,148 @@
+package google
+
+import (
+	"fmt"
+
+	"code.google.com/p/google-api-go-client/compute/v1"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// This file holds the pieces of compute.Instance construction that are
+// identical between google_compute_instance and google_compute_instance_template:
+// both build their Metadata, Tags, NetworkInterfaces, and ServiceAccounts
+// off the same schema fields.
+
+// resourceInstanceMetadata builds compute.Metadata from the "metadata" map,
+// merging in "metadata_startup_script" as the "startup-script" key if set.
+// metadata_startup_script takes precedence over a "startup-script" entry
+// placed directly in the metadata map, since it's the more specific of the
+// two ways to set it.
+func resourceInstanceMetadata(d *schema.ResourceData) *compute.Metadata {
+	metadataMap := d.Get("metadata").(map[string]interface{})
+	startupScript := d.Get("metadata_startup_script").(string)
+	if len(metadataMap) == 0 && startupScript == "" {
+		return nil
+	}
+
+	m := new(compute.Metadata)
+	m.Items = make([]*compute.MetadataItems, 0, len(metadataMap)+1)
+	for key, val := range metadataMap {
+		if key == "startup-script" {
+			// metadata_startup_script, below, is the preferred way to set
+			// this key; skip the map's copy so it doesn't collide.
+			continue
+		}
+		m.Items = append(m.Items, &compute.MetadataItems{
+			Key:   key,
+			Value: val.(string),
+		})
+	}
+
+	if startupScript == "" {
+		if v, ok := metadataMap["startup-script"]; ok {
+			startupScript = v.(string)
+		}
+	}
+	if startupScript != "" {
+		m.Items = append(m.Items, &compute.MetadataItems{
+			Key:   "startup-script",
+			Value: startupScript,
+		})
+	}
+
+	// Set the fingerprint. If the metadata has never been set before
+	// then this will just be blank.
+	m.Fingerprint = d.Get("metadata_fingerprint").(string)
+
+	return m
+}
+
+func resourceInstanceTags(d *schema.ResourceData) *compute.Tags {
+	// Calculate the tags
+	var tags *compute.Tags
+	if v := d.Get("tags"); v != nil {
+		vs := v.(*schema.Set)
+		tags = new(compute.Tags)
+		tags.Items = make([]string, vs.Len())
+		for i, v := range vs.List() {
+			tags.Items[i] = v.(string)
+		}
+
+		tags.Fingerprint = d.Get("tags_fingerprint").(string)
+	}
+
+	return tags
+}
+
+// resourceInstanceNatIP acquires the first NatIP with a "ONE_TO_ONE_NAT" type
+// in the compute.NetworkInterface's AccessConfigs.
+func resourceInstanceNatIP(iface *compute.NetworkInterface) (natIP string) {
+	for _, config := range iface.AccessConfigs {
+		if config.Type == "ONE_TO_ONE_NAT" {
+			natIP = config.NatIP
+			break
+		}
+	}
+
+	return natIP
+}
+
+// expandNetworkInterfaces builds the compute.NetworkInterface list from the
+// "network" blocks, shared by google_compute_instance and
+// google_compute_instance_template.
+func expandNetworkInterfaces(d *schema.ResourceData, config *Config) ([]*compute.NetworkInterface, error) {
+	networksCount := d.Get("network.#").(int)
+	networks := make([]*compute.NetworkInterface, 0, networksCount)
+	for i := 0; i < networksCount; i++ {
+		prefix := fmt.Sprintf("network.%d", i)
+		// Load up the name of this network
+		networkName := d.Get(prefix + ".source").(string)
+		network, err := config.clientCompute.Networks.Get(
+			config.Project, networkName).Do()
+		if err != nil {
+			return nil, fmt.Errorf(
+				"Error loading network '%s': %s",
+				networkName, err)
+		}
+
+		var iface compute.NetworkInterface
+		iface.AccessConfigs = []*compute.AccessConfig{
+			&compute.AccessConfig{
+				Type:  "ONE_TO_ONE_NAT",
+				NatIP: d.Get(prefix + ".address").(string),
+			},
+		}
+		iface.Network = network.SelfLink
+
+		networks = append(networks, &iface)
+	}
+
+	return networks, nil
+}
+
+// expandServiceAccounts builds the compute.ServiceAccount list from the
+// "service_account" blocks, shared by google_compute_instance and
+// google_compute_instance_template.
+func expandServiceAccounts(d *schema.ResourceData) []*compute.ServiceAccount {
+	serviceAccountsCount := d.Get("service_account.#").(int)
+	serviceAccounts := make([]*compute.ServiceAccount, 0, serviceAccountsCount)
+	for i := 0; i < serviceAccountsCount; i++ {
+		prefix := fmt.Sprintf("service_account.%d", i)
+
+		scopesCount := d.Get(prefix + ".scopes.#").(int)
+		scopes := make([]string, 0, scopesCount)
+		for j := 0; j < scopesCount; j++ {
+			scope := d.Get(fmt.Sprintf(prefix+".scopes.%d", j)).(string)
+			scopes = append(scopes, canonicalizeServiceScope(scope))
+		}
+
+		serviceAccount := &compute.ServiceAccount{
+			Email:  "default",
+			Scopes: scopes,
+		}
+
+		serviceAccounts = append(serviceAccounts, serviceAccount)
+	}
+
+	return serviceAccounts
+}
@@ -0,0 +1,312 @@
+package google
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"code.google.com/p/google-api-go-client/compute/v1"
+	"code.google.com/p/google-api-go-client/googleapi"
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourceComputeInstanceTemplate describes a reusable instance
+// configuration for instance groups / autoscalers. Unlike
+// google_compute_instance, a template is immutable once created: every
+// field is ForceNew, and there is no Update.
+func resourceComputeInstanceTemplate() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceComputeInstanceTemplateCreate,
+		Read:   resourceComputeInstanceTemplateRead,
+		Delete: resourceComputeInstanceTemplateDelete,
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"machine_type": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+
+			"can_ip_forward": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				ForceNew: true,
+			},
+
+			// boot_disk mirrors google_compute_instance's block, minus the
+			// zone-scoped "source" and "type" lookups: a template isn't
+			// bound to a zone, so disk_type is passed through as the plain
+			// short name (e.g. "pd-ssd") instead of being resolved to a
+			// zonal self_link.
+			"boot_disk": &schema.Schema{
+				Type:     schema.TypeList,
+				Required: true,
+				ForceNew: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"auto_delete": &schema.Schema{
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+							ForceNew: true,
+						},
+
+						"disk_type": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Computed: true,
+							ForceNew: true,
+						},
+
+						"disk_size_gb": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+							Computed: true,
+							ForceNew: true,
+						},
+
+						"source_image": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+					},
+				},
+			},
+
+			"network": &schema.Schema{
+				Type:     schema.TypeList,
+				Required: true,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"source": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+
+						"address": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							ForceNew: true,
+						},
+					},
+				},
+			},
+
+			"service_account": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"email": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+							ForceNew: true,
+						},
+
+						"scopes": &schema.Schema{
+							Type:     schema.TypeList,
+							Required: true,
+							ForceNew: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+								StateFunc: func(v interface{}) string {
+									return canonicalizeServiceScope(v.(string))
+								},
+							},
+						},
+					},
+				},
+			},
+
+			"tags": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				ForceNew: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set: func(v interface{}) int {
+					return hashcode.String(v.(string))
+				},
+			},
+
+			// resourceInstanceTags (shared with google_compute_instance)
+			// always reads this key; see metadata_fingerprint below.
+			"tags_fingerprint": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"metadata": &schema.Schema{
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			"metadata_startup_script": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+
+			// resourceInstanceMetadata (shared with google_compute_instance)
+			// always reads this key; a template's metadata fingerprint is
+			// never actually reused across updates since the resource is
+			// immutable, but the field has to exist for the shared helper
+			// to call d.Get on it.
+			"metadata_fingerprint": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+
+			"self_link": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceComputeInstanceTemplateCreate(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	machineType := d.Get("machine_type").(string)
+
+	disk := &compute.AttachedDisk{
+		Type:       "PERSISTENT",
+		Mode:       "READ_WRITE",
+		Boot:       true,
+		AutoDelete: d.Get("boot_disk.0.auto_delete").(bool),
+	}
+
+	imageUrl, err := resolveImage(config, d.Get("boot_disk.0.source_image").(string))
+	if err != nil {
+		return fmt.Errorf("Error resolving image name '%s': %s", d.Get("boot_disk.0.source_image").(string), err)
+	}
+
+	params := &compute.AttachedDiskInitializeParams{
+		SourceImage: imageUrl,
+	}
+	if v, ok := d.GetOk("boot_disk.0.disk_type"); ok {
+		params.DiskType = v.(string)
+	}
+	if v, ok := d.GetOk("boot_disk.0.disk_size_gb"); ok {
+		params.DiskSizeGb = int64(v.(int))
+	}
+	disk.InitializeParams = params
+
+	networks, err := expandNetworkInterfaces(d, config)
+	if err != nil {
+		return err
+	}
+
+	template := &compute.InstanceTemplate{
+		Name: d.Get("name").(string),
+		Properties: &compute.InstanceProperties{
+			CanIpForward:      d.Get("can_ip_forward").(bool),
+			Description:       d.Get("description").(string),
+			Disks:             []*compute.AttachedDisk{disk},
+			MachineType:       machineType,
+			Metadata:          resourceInstanceMetadata(d),
+			NetworkInterfaces: networks,
+			Tags:              resourceInstanceTags(d),
+			ServiceAccounts:   expandServiceAccounts(d),
+		},
+	}
+
+	log.Printf("[INFO] Requesting instance template creation")
+	op, err := config.clientCompute.InstanceTemplates.Insert(config.Project, template).Do()
+	if err != nil {
+		return fmt.Errorf("Error creating instance template: %s", err)
+	}
+
+	d.SetId(template.Name)
+
+	w := &OperationWaiter{
+		Service: config.clientCompute,
+		Op:      op,
+		Project: config.Project,
+		Type:    OperationWaitGlobal,
+	}
+	state := w.Conf()
+	state.Delay = 5 * time.Second
+	state.Timeout = 5 * time.Minute
+	state.MinTimeout = 2 * time.Second
+	opRaw, err := state.WaitForState()
+	if err != nil {
+		return fmt.Errorf("Error waiting for instance template to create: %s", err)
+	}
+	op = opRaw.(*compute.Operation)
+	if op.Error != nil {
+		d.SetId("")
+		return OperationError(*op.Error)
+	}
+
+	return resourceComputeInstanceTemplateRead(d, meta)
+}
+
+func resourceComputeInstanceTemplateRead(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	template, err := config.clientCompute.InstanceTemplates.Get(config.Project, d.Id()).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 404 {
+			d.SetId("")
+			return nil
+		}
+
+		return fmt.Errorf("Error reading instance template: %s", err)
+	}
+
+	d.Set("self_link", template.SelfLink)
+
+	return nil
+}
+
+func resourceComputeInstanceTemplateDelete(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+
+	op, err := config.clientCompute.InstanceTemplates.Delete(config.Project, d.Id()).Do()
+	if err != nil {
+		return fmt.Errorf("Error deleting instance template: %s", err)
+	}
+
+	w := &OperationWaiter{
+		Service: config.clientCompute,
+		Op:      op,
+		Project: config.Project,
+		Type:    OperationWaitGlobal,
+	}
+	state := w.Conf()
+	state.Delay = 5 * time.Second
+	state.Timeout = 5 * time.Minute
+	state.MinTimeout = 2 * time.Second
+	opRaw, err := state.WaitForState()
+	if err != nil {
+		return fmt.Errorf("Error waiting for instance template to delete: %s", err)
+	}
+	op = opRaw.(*compute.Operation)
+	if op.Error != nil {
+		return OperationError(*op.Error)
+	}
+
+	d.SetId("")
+	return nil
+}
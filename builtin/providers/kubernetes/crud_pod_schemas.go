@@ -1,11 +1,71 @@
 package kubernetes
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
 	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/api/unversioned"
 	"k8s.io/kubernetes/pkg/util"
 )
 
+// StrictSchema is a provider-level option. When true, a decoded HCL map
+// whose keys don't all match what a create* function knows how to convert
+// is an error instead of being silently ignored by the `if val, ok :=
+// _map[key]; ok` pattern this file otherwise relies on throughout - the
+// same pattern that let the "sercret"/"Protocol" key-name typos go
+// unnoticed. It defaults to false since turning it on can break existing
+// configurations that happen to rely on an unrecognized key being a no-op.
+var StrictSchema = false
+
+// validateUnknownKeys returns an error naming every key in _map that isn't
+// listed in known, when StrictSchema is enabled; it's a no-op otherwise.
+// context identifies the HCL block the map came from, for the error
+// message.
+func validateUnknownKeys(context string, _map map[string]interface{}, known ...string) error {
+	if !StrictSchema {
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(known))
+	for _, k := range known {
+		allowed[k] = true
+	}
+
+	var unknown []string
+	for k := range _map {
+		if !allowed[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	return fmt.Errorf("%s: unrecognized key(s) %s", context, strings.Join(unknown, ", "))
+}
+
+// csiDriverNameRegexp matches a valid CSI driver name: a reverse-DNS-style
+// identifier, the same format the CSI spec requires (e.g.
+// "ebs.csi.aws.com", "rbd.csi.ceph.com").
+var csiDriverNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// validateCsiDriverName is meant for use as a schema.Schema ValidateFunc on
+// a `csi.driver` attribute, rejecting driver names that can't possibly
+// match a real CSI driver's registration name before a plan is ever sent
+// to the API server.
+func validateCsiDriverName(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+	if len(value) > 63 || !csiDriverNameRegexp.MatchString(value) {
+		errors = append(errors, fmt.Errorf("%q must be a valid CSI driver name (lowercase, reverse-DNS style, max 63 characters), got: %q", k, value))
+	}
+	return
+}
+
 func createStringList(_values []interface{}) []string {
 	values := make([]string, len(_values))
 	for i, v := range _values {
@@ -14,7 +74,7 @@ func createStringList(_values []interface{}) []string {
 	return values
 }
 
-func createVolumes(_volumes []interface{}) []api.Volume {
+func createVolumes(_volumes []interface{}) ([]api.Volume, error) {
 	volumes := make([]api.Volume, len(_volumes))
 	for i, v := range _volumes {
 		_volume := v.(map[string]interface{})
@@ -22,7 +82,10 @@ func createVolumes(_volumes []interface{}) []api.Volume {
 
 		volume.Name = _volume["name"].(string)
 
-		volumeSource := createVolumeSource(_volume["volume_source"].([]interface{}))
+		volumeSource, err := createVolumeSource(_volume["volume_source"].([]interface{}))
+		if err != nil {
+			return nil, err
+		}
 
 		if volumeSource != nil {
 			volume.HostPath = volumeSource.HostPath
@@ -36,23 +99,43 @@ func createVolumes(_volumes []interface{}) []api.Volume {
 			volume.Glusterfs = volumeSource.Glusterfs
 			volume.PersistentVolumeClaim = volumeSource.PersistentVolumeClaim
 			volume.Cinder = volumeSource.Cinder
+			volume.RBD = volumeSource.RBD
 			volume.CephFS = volumeSource.CephFS
 			volume.Flocker = volumeSource.Flocker
 			volume.DownwardAPI = volumeSource.DownwardAPI
 			volume.FC = volumeSource.FC
+			volume.ConfigMap = volumeSource.ConfigMap
+			volume.Projected = volumeSource.Projected
+			volume.AzureDisk = volumeSource.AzureDisk
+			volume.AzureFile = volumeSource.AzureFile
+			volume.FlexVolume = volumeSource.FlexVolume
+			volume.CSI = volumeSource.CSI
 		}
 
 		volumes[i] = volume
 	}
 
-	return volumes
+	return volumes, nil
 }
 
-func createVolumeSource(_volume_sources []interface{}) *api.VolumeSource {
+// createVolumeSource converts a single `volume_source` block. Its keys are
+// checked against StrictSchema - unlike most create* functions in this
+// file, which would otherwise silently drop a mistyped key the way the
+// "sercret"/"Protocol" bugs once did here.
+func createVolumeSource(_volume_sources []interface{}) (*api.VolumeSource, error) {
 	if len(_volume_sources) == 0 {
-		return nil
+		return nil, nil
 	} else {
 		_volume_source := _volume_sources[0].(map[string]interface{})
+
+		if err := validateUnknownKeys("volume_source", _volume_source,
+			"host_path", "empty_dir", "gce_persistent_disk", "aws_elastic_block_store",
+			"git_repo", "secret", "nfs", "iscsi", "gluster_fs", "persistent_volume_claim",
+			"cinder", "rbd", "cephfs", "flocker", "downward_api", "fc", "config_map",
+			"projected", "azure_disk", "azure_file", "flex_volume", "csi"); err != nil {
+			return nil, err
+		}
+
 		volumeSource := &api.VolumeSource{}
 
 		if val, ok := _volume_source["host_path"]; ok {
@@ -75,7 +158,7 @@ func createVolumeSource(_volume_sources []interface{}) *api.VolumeSource {
 			volumeSource.GitRepo = createGitRepoVolumeSource(val.([]interface{}))
 		}
 
-		if val, ok := _volume_source["sercret"]; ok {
+		if val, ok := _volume_source["secret"]; ok {
 			volumeSource.Secret = createSecretVolumeSource(val.([]interface{}))
 		}
 
@@ -99,6 +182,10 @@ func createVolumeSource(_volume_sources []interface{}) *api.VolumeSource {
 			volumeSource.Cinder = createCinderVolumeSource(val.([]interface{}))
 		}
 
+		if val, ok := _volume_source["rbd"]; ok {
+			volumeSource.RBD = createRbdVolumeSource(val.([]interface{}))
+		}
+
 		if val, ok := _volume_source["cephfs"]; ok {
 			volumeSource.CephFS = createCephFsVolumeSource(val.([]interface{}))
 		}
@@ -115,7 +202,31 @@ func createVolumeSource(_volume_sources []interface{}) *api.VolumeSource {
 			volumeSource.FC = createFcVolumeSource(val.([]interface{}))
 		}
 
-		return volumeSource
+		if val, ok := _volume_source["config_map"]; ok {
+			volumeSource.ConfigMap = createConfigMapVolumeSource(val.([]interface{}))
+		}
+
+		if val, ok := _volume_source["projected"]; ok {
+			volumeSource.Projected = createProjectedVolumeSource(val.([]interface{}))
+		}
+
+		if val, ok := _volume_source["azure_disk"]; ok {
+			volumeSource.AzureDisk = createAzureDiskVolumeSource(val.([]interface{}))
+		}
+
+		if val, ok := _volume_source["azure_file"]; ok {
+			volumeSource.AzureFile = createAzureFileVolumeSource(val.([]interface{}))
+		}
+
+		if val, ok := _volume_source["flex_volume"]; ok {
+			volumeSource.FlexVolume = createFlexVolumeSource(val.([]interface{}))
+		}
+
+		if val, ok := _volume_source["csi"]; ok {
+			volumeSource.CSI = createCsiVolumeSource(val.([]interface{}))
+		}
+
+		return volumeSource, nil
 	}
 }
 
@@ -433,6 +544,10 @@ func createCephFsVolumeSource(_ceph_fss []interface{}) *api.CephFSVolumeSource {
 			cephFs.SecretFile = val.(string)
 		}
 
+		if val, ok := _ceph_fs["path"]; ok {
+			cephFs.Path = val.(string)
+		}
+
 		if val, ok := _ceph_fs["secret_ref"]; ok {
 			cephFs.SecretRef = createLocalObjectReference(val.([]interface{}))
 		}
@@ -456,6 +571,10 @@ func createFlockerVolumeSource(_flockers []interface{}) *api.FlockerVolumeSource
 			flocker.DatasetName = val.(string)
 		}
 
+		if val, ok := _flocker["dataset_uuid"]; ok {
+			flocker.DatasetUUID = val.(string)
+		}
+
 		return flocker
 	}
 }
@@ -522,6 +641,325 @@ func createFcVolumeSource(_fcs []interface{}) *api.FCVolumeSource {
 	}
 }
 
+func createConfigMapVolumeSource(_config_maps []interface{}) *api.ConfigMapVolumeSource {
+	if len(_config_maps) == 0 {
+		return nil
+	} else {
+		_config_map := _config_maps[0].(map[string]interface{})
+		configMap := &api.ConfigMapVolumeSource{}
+
+		if val, ok := _config_map["name"]; ok {
+			configMap.Name = val.(string)
+		}
+
+		if val, ok := _config_map["items"]; ok {
+			configMap.Items = createKeyToPaths(val.([]interface{}))
+		}
+
+		if val, ok := _config_map["default_mode"]; ok {
+			v := int32(val.(int))
+			configMap.DefaultMode = &v
+		}
+
+		if val, ok := _config_map["optional"]; ok {
+			v := val.(bool)
+			configMap.Optional = &v
+		}
+
+		return configMap
+	}
+}
+
+func createKeyToPaths(_items []interface{}) []api.KeyToPath {
+	items := make([]api.KeyToPath, len(_items))
+	for i, v := range _items {
+		_item := v.(map[string]interface{})
+		item := api.KeyToPath{
+			Key:  _item["key"].(string),
+			Path: _item["path"].(string),
+		}
+
+		if val, ok := _item["mode"]; ok {
+			m := int32(val.(int))
+			item.Mode = &m
+		}
+
+		items[i] = item
+	}
+	return items
+}
+
+// createProjectedVolumeSource combines any number of secret, configMap,
+// downwardAPI, and serviceAccountToken sources into a single volume, the
+// same way api.ProjectedVolumeSource lets a pod mount several otherwise
+// unrelated sources at different paths under one volume.
+func createProjectedVolumeSource(_projecteds []interface{}) *api.ProjectedVolumeSource {
+	if len(_projecteds) == 0 {
+		return nil
+	} else {
+		_projected := _projecteds[0].(map[string]interface{})
+		projected := &api.ProjectedVolumeSource{}
+
+		if val, ok := _projected["sources"]; ok {
+			projected.Sources = createVolumeProjections(val.([]interface{}))
+		}
+
+		if val, ok := _projected["default_mode"]; ok {
+			v := int32(val.(int))
+			projected.DefaultMode = &v
+		}
+
+		return projected
+	}
+}
+
+func createVolumeProjections(_sources []interface{}) []api.VolumeProjection {
+	sources := make([]api.VolumeProjection, len(_sources))
+	for i, v := range _sources {
+		_source := v.(map[string]interface{})
+		source := api.VolumeProjection{}
+
+		if val, ok := _source["secret"]; ok {
+			source.Secret = createSecretProjection(val.([]interface{}))
+		}
+
+		if val, ok := _source["config_map"]; ok {
+			source.ConfigMap = createConfigMapProjection(val.([]interface{}))
+		}
+
+		if val, ok := _source["downward_api"]; ok {
+			source.DownwardAPI = createDownwardApiProjection(val.([]interface{}))
+		}
+
+		if val, ok := _source["service_account_token"]; ok {
+			source.ServiceAccountToken = createServiceAccountTokenProjection(val.([]interface{}))
+		}
+
+		sources[i] = source
+	}
+	return sources
+}
+
+func createSecretProjection(_secrets []interface{}) *api.SecretProjection {
+	if len(_secrets) == 0 {
+		return nil
+	} else {
+		_secret := _secrets[0].(map[string]interface{})
+		secret := &api.SecretProjection{}
+
+		if val, ok := _secret["name"]; ok {
+			secret.Name = val.(string)
+		}
+
+		if val, ok := _secret["items"]; ok {
+			secret.Items = createKeyToPaths(val.([]interface{}))
+		}
+
+		if val, ok := _secret["optional"]; ok {
+			v := val.(bool)
+			secret.Optional = &v
+		}
+
+		return secret
+	}
+}
+
+func createConfigMapProjection(_config_maps []interface{}) *api.ConfigMapProjection {
+	if len(_config_maps) == 0 {
+		return nil
+	} else {
+		_config_map := _config_maps[0].(map[string]interface{})
+		configMap := &api.ConfigMapProjection{}
+
+		if val, ok := _config_map["name"]; ok {
+			configMap.Name = val.(string)
+		}
+
+		if val, ok := _config_map["items"]; ok {
+			configMap.Items = createKeyToPaths(val.([]interface{}))
+		}
+
+		if val, ok := _config_map["optional"]; ok {
+			v := val.(bool)
+			configMap.Optional = &v
+		}
+
+		return configMap
+	}
+}
+
+func createDownwardApiProjection(_downward_apis []interface{}) *api.DownwardAPIProjection {
+	if len(_downward_apis) == 0 {
+		return nil
+	} else {
+		_downward_api := _downward_apis[0].(map[string]interface{})
+		downwardApi := &api.DownwardAPIProjection{}
+
+		if val, ok := _downward_api["items"]; ok {
+			downwardApi.Items = createDownwardApiVolumeFiles(val.([]interface{}))
+		}
+
+		return downwardApi
+	}
+}
+
+func createServiceAccountTokenProjection(_tokens []interface{}) *api.ServiceAccountTokenProjection {
+	if len(_tokens) == 0 {
+		return nil
+	} else {
+		_token := _tokens[0].(map[string]interface{})
+		token := &api.ServiceAccountTokenProjection{}
+
+		if val, ok := _token["audience"]; ok {
+			token.Audience = val.(string)
+		}
+
+		if val, ok := _token["expiration_seconds"]; ok {
+			v := int64(val.(int))
+			token.ExpirationSeconds = &v
+		}
+
+		if val, ok := _token["path"]; ok {
+			token.Path = val.(string)
+		}
+
+		return token
+	}
+}
+
+func createAzureDiskVolumeSource(_azure_disks []interface{}) *api.AzureDiskVolumeSource {
+	if len(_azure_disks) == 0 {
+		return nil
+	} else {
+		_azure_disk := _azure_disks[0].(map[string]interface{})
+		azureDisk := &api.AzureDiskVolumeSource{}
+
+		if val, ok := _azure_disk["disk_name"]; ok {
+			azureDisk.DiskName = val.(string)
+		}
+
+		if val, ok := _azure_disk["data_disk_uri"]; ok {
+			azureDisk.DataDiskURI = val.(string)
+		}
+
+		if val, ok := _azure_disk["caching_mode"]; ok {
+			v := api.AzureDataDiskCachingMode(val.(string))
+			azureDisk.CachingMode = &v
+		}
+
+		if val, ok := _azure_disk["fs_type"]; ok {
+			v := val.(string)
+			azureDisk.FSType = &v
+		}
+
+		if val, ok := _azure_disk["read_only"]; ok {
+			v := val.(bool)
+			azureDisk.ReadOnly = &v
+		}
+
+		return azureDisk
+	}
+}
+
+func createAzureFileVolumeSource(_azure_files []interface{}) *api.AzureFileVolumeSource {
+	if len(_azure_files) == 0 {
+		return nil
+	} else {
+		_azure_file := _azure_files[0].(map[string]interface{})
+		azureFile := &api.AzureFileVolumeSource{}
+
+		if val, ok := _azure_file["secret_name"]; ok {
+			azureFile.SecretName = val.(string)
+		}
+
+		if val, ok := _azure_file["share_name"]; ok {
+			azureFile.ShareName = val.(string)
+		}
+
+		if val, ok := _azure_file["read_only"]; ok {
+			azureFile.ReadOnly = val.(bool)
+		}
+
+		return azureFile
+	}
+}
+
+func createFlexVolumeSource(_flex_volumes []interface{}) *api.FlexVolumeSource {
+	if len(_flex_volumes) == 0 {
+		return nil
+	} else {
+		_flex_volume := _flex_volumes[0].(map[string]interface{})
+		flexVolume := &api.FlexVolumeSource{}
+
+		if val, ok := _flex_volume["driver"]; ok {
+			flexVolume.Driver = val.(string)
+		}
+
+		if val, ok := _flex_volume["fs_type"]; ok {
+			flexVolume.FSType = val.(string)
+		}
+
+		if val, ok := _flex_volume["secret_ref"]; ok {
+			flexVolume.SecretRef = createLocalObjectReference(val.([]interface{}))
+		}
+
+		if val, ok := _flex_volume["read_only"]; ok {
+			flexVolume.ReadOnly = val.(bool)
+		}
+
+		if val, ok := _flex_volume["options"]; ok {
+			options := val.(map[string]interface{})
+			flexVolume.Options = make(map[string]string, len(options))
+			for k, v := range options {
+				flexVolume.Options[k] = v.(string)
+			}
+		}
+
+		return flexVolume
+	}
+}
+
+func createCsiVolumeSource(_csis []interface{}) *api.CSIVolumeSource {
+	if len(_csis) == 0 {
+		return nil
+	} else {
+		_csi := _csis[0].(map[string]interface{})
+		csi := &api.CSIVolumeSource{}
+
+		if val, ok := _csi["driver"]; ok {
+			csi.Driver = val.(string)
+		}
+
+		if val, ok := _csi["volume_handle"]; ok {
+			csi.VolumeHandle = val.(string)
+		}
+
+		if val, ok := _csi["read_only"]; ok {
+			v := val.(bool)
+			csi.ReadOnly = &v
+		}
+
+		if val, ok := _csi["fs_type"]; ok {
+			v := val.(string)
+			csi.FSType = &v
+		}
+
+		if val, ok := _csi["volume_attributes"]; ok {
+			attributes := val.(map[string]interface{})
+			csi.VolumeAttributes = make(map[string]string, len(attributes))
+			for k, v := range attributes {
+				csi.VolumeAttributes[k] = v.(string)
+			}
+		}
+
+		if val, ok := _csi["node_publish_secret_ref"]; ok {
+			csi.NodePublishSecretRef = createLocalObjectReference(val.([]interface{}))
+		}
+
+		return csi
+	}
+}
+
 func createContainers(_containers []interface{}) []api.Container {
 	containers := make([]api.Container, len(_containers))
 	for i, v := range _containers {
@@ -612,7 +1050,7 @@ func createContainerPorts(_ports []interface{}) []api.ContainerPort {
 
 		port.ContainerPort = _port["container_port"].(int)
 
-		port.Protocol = api.Protocol(_port["Protocol"].(string))
+		port.Protocol = api.Protocol(_port["protocol"].(string))
 
 		if val, ok := _port["host_ip"]; ok {
 			port.HostIP = val.(string)
@@ -690,8 +1128,8 @@ func createResourceRequirements(_resource_reqs []interface{}) *api.ResourceRequi
 func createResourceList(_resource_list map[string]interface{}) map[api.ResourceName]resource.Quantity {
 	resource_list := make(map[api.ResourceName]resource.Quantity, len(_resource_list))
 	for k, v := range(_resource_list) {
-		if q, err := resource.ParseQuantity(v.(string)); err == nil && q != nil {
-			resource_list[api.ResourceName(k)] = *q
+		if q, err := resource.ParseQuantity(v.(string)); err == nil {
+			resource_list[api.ResourceName(k)] = q
 		}
 	}
 	return resource_list
@@ -913,3 +1351,323 @@ func createSeLinuxOptions(_se_linux_options []interface{}) *api.SELinuxOptions {
 		return seLinuxOption
 	}
 }
+
+func createInt64List(_values []interface{}) []int64 {
+	values := make([]int64, len(_values))
+	for i, v := range _values {
+		values[i] = int64(v.(int))
+	}
+	return values
+}
+
+// createPodSecurityContext converts a pod-level `security_context` block.
+// Unlike createSecurityContext above, which applies to a single container,
+// this one carries the settings (fsGroup, supplementalGroups, runAsUser,
+// runAsNonRoot, seLinuxOptions) that only make sense at the scope of the
+// whole pod.
+func createPodSecurityContext(_security_contexts []interface{}) *api.PodSecurityContext {
+	if len(_security_contexts) == 0 {
+		return nil
+	} else {
+		_security_context := _security_contexts[0].(map[string]interface{})
+		securityContext := &api.PodSecurityContext{}
+
+		if val, ok := _security_context["se_linux_options"]; ok {
+			securityContext.SELinuxOptions = createSeLinuxOptions(val.([]interface{}))
+		}
+
+		if val, ok := _security_context["run_as_user"]; ok {
+			v := int64(val.(int))
+			securityContext.RunAsUser = &v
+		}
+
+		if val, ok := _security_context["run_as_non_root"]; ok {
+			v := val.(bool)
+			securityContext.RunAsNonRoot = &v
+		}
+
+		if val, ok := _security_context["supplemental_groups"]; ok {
+			securityContext.SupplementalGroups = createInt64List(val.([]interface{}))
+		}
+
+		if val, ok := _security_context["fs_group"]; ok {
+			v := int64(val.(int))
+			securityContext.FSGroup = &v
+		}
+
+		return securityContext
+	}
+}
+
+// createAffinity converts an `affinity` block into the scheduling
+// constraints it represents. Terraform config only ever fills in the
+// RequiredDuringSchedulingIgnoredDuringExecution/PreferredDuringScheduling...
+// pair of each sub-type; there's no "DuringExecution" variant yet upstream,
+// so that's all these builders need to support.
+func createAffinity(_affinities []interface{}) *api.Affinity {
+	if len(_affinities) == 0 {
+		return nil
+	} else {
+		_affinity := _affinities[0].(map[string]interface{})
+		affinity := &api.Affinity{}
+
+		if val, ok := _affinity["node_affinity"]; ok {
+			affinity.NodeAffinity = createNodeAffinity(val.([]interface{}))
+		}
+
+		if val, ok := _affinity["pod_affinity"]; ok {
+			affinity.PodAffinity = createPodAffinity(val.([]interface{}))
+		}
+
+		if val, ok := _affinity["pod_anti_affinity"]; ok {
+			affinity.PodAntiAffinity = createPodAntiAffinity(val.([]interface{}))
+		}
+
+		return affinity
+	}
+}
+
+func createNodeAffinity(_node_affinities []interface{}) *api.NodeAffinity {
+	if len(_node_affinities) == 0 {
+		return nil
+	} else {
+		_node_affinity := _node_affinities[0].(map[string]interface{})
+		nodeAffinity := &api.NodeAffinity{}
+
+		if val, ok := _node_affinity["required_during_scheduling_ignored_during_execution"]; ok {
+			nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = createNodeSelector(val.([]interface{}))
+		}
+
+		if val, ok := _node_affinity["preferred_during_scheduling_ignored_during_execution"]; ok {
+			nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = createPreferredSchedulingTerms(val.([]interface{}))
+		}
+
+		return nodeAffinity
+	}
+}
+
+func createNodeSelector(_node_selectors []interface{}) *api.NodeSelector {
+	if len(_node_selectors) == 0 {
+		return nil
+	} else {
+		_node_selector := _node_selectors[0].(map[string]interface{})
+		nodeSelector := &api.NodeSelector{}
+
+		if val, ok := _node_selector["node_selector_term"]; ok {
+			nodeSelector.NodeSelectorTerms = createNodeSelectorTerms(val.([]interface{}))
+		}
+
+		return nodeSelector
+	}
+}
+
+func createNodeSelectorTerms(_terms []interface{}) []api.NodeSelectorTerm {
+	terms := make([]api.NodeSelectorTerm, len(_terms))
+	for i, v := range _terms {
+		_term := v.(map[string]interface{})
+		term := api.NodeSelectorTerm{}
+
+		if val, ok := _term["match_expression"]; ok {
+			term.MatchExpressions = createNodeSelectorRequirements(val.([]interface{}))
+		}
+
+		terms[i] = term
+	}
+	return terms
+}
+
+func createNodeSelectorRequirements(_requirements []interface{}) []api.NodeSelectorRequirement {
+	requirements := make([]api.NodeSelectorRequirement, len(_requirements))
+	for i, v := range _requirements {
+		_requirement := v.(map[string]interface{})
+		requirement := api.NodeSelectorRequirement{}
+
+		requirement.Key = _requirement["key"].(string)
+		requirement.Operator = api.NodeSelectorOperator(_requirement["operator"].(string))
+
+		if val, ok := _requirement["values"]; ok {
+			requirement.Values = createStringList(val.([]interface{}))
+		}
+
+		requirements[i] = requirement
+	}
+	return requirements
+}
+
+func createPreferredSchedulingTerms(_terms []interface{}) []api.PreferredSchedulingTerm {
+	terms := make([]api.PreferredSchedulingTerm, len(_terms))
+	for i, v := range _terms {
+		_term := v.(map[string]interface{})
+		term := api.PreferredSchedulingTerm{}
+
+		term.Weight = int32(_term["weight"].(int))
+
+		if val, ok := _term["preference"]; ok {
+			preferences := createNodeSelectorTerms(val.([]interface{}))
+			if len(preferences) > 0 {
+				term.Preference = preferences[0]
+			}
+		}
+
+		terms[i] = term
+	}
+	return terms
+}
+
+func createPodAffinity(_pod_affinities []interface{}) *api.PodAffinity {
+	if len(_pod_affinities) == 0 {
+		return nil
+	} else {
+		_pod_affinity := _pod_affinities[0].(map[string]interface{})
+		podAffinity := &api.PodAffinity{}
+
+		if val, ok := _pod_affinity["required_during_scheduling_ignored_during_execution"]; ok {
+			podAffinity.RequiredDuringSchedulingIgnoredDuringExecution = createPodAffinityTerms(val.([]interface{}))
+		}
+
+		if val, ok := _pod_affinity["preferred_during_scheduling_ignored_during_execution"]; ok {
+			podAffinity.PreferredDuringSchedulingIgnoredDuringExecution = createWeightedPodAffinityTerms(val.([]interface{}))
+		}
+
+		return podAffinity
+	}
+}
+
+func createPodAntiAffinity(_pod_anti_affinities []interface{}) *api.PodAntiAffinity {
+	if len(_pod_anti_affinities) == 0 {
+		return nil
+	} else {
+		_pod_anti_affinity := _pod_anti_affinities[0].(map[string]interface{})
+		podAntiAffinity := &api.PodAntiAffinity{}
+
+		if val, ok := _pod_anti_affinity["required_during_scheduling_ignored_during_execution"]; ok {
+			podAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = createPodAffinityTerms(val.([]interface{}))
+		}
+
+		if val, ok := _pod_anti_affinity["preferred_during_scheduling_ignored_during_execution"]; ok {
+			podAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = createWeightedPodAffinityTerms(val.([]interface{}))
+		}
+
+		return podAntiAffinity
+	}
+}
+
+func createPodAffinityTerms(_terms []interface{}) []api.PodAffinityTerm {
+	terms := make([]api.PodAffinityTerm, len(_terms))
+	for i, v := range _terms {
+		terms[i] = createPodAffinityTerm(v.(map[string]interface{}))
+	}
+	return terms
+}
+
+func createPodAffinityTerm(_term map[string]interface{}) api.PodAffinityTerm {
+	term := api.PodAffinityTerm{}
+
+	if val, ok := _term["label_selector"]; ok {
+		term.LabelSelector = createLabelSelector(val.([]interface{}))
+	}
+
+	if val, ok := _term["namespaces"]; ok {
+		term.Namespaces = createStringList(val.([]interface{}))
+	}
+
+	if val, ok := _term["topology_key"]; ok {
+		term.TopologyKey = val.(string)
+	}
+
+	return term
+}
+
+func createWeightedPodAffinityTerms(_terms []interface{}) []api.WeightedPodAffinityTerm {
+	terms := make([]api.WeightedPodAffinityTerm, len(_terms))
+	for i, v := range _terms {
+		_term := v.(map[string]interface{})
+		term := api.WeightedPodAffinityTerm{}
+
+		term.Weight = int32(_term["weight"].(int))
+
+		if val, ok := _term["pod_affinity_term"]; ok {
+			if podAffinityTerms := val.([]interface{}); len(podAffinityTerms) > 0 {
+				term.PodAffinityTerm = createPodAffinityTerm(podAffinityTerms[0].(map[string]interface{}))
+			}
+		}
+
+		terms[i] = term
+	}
+	return terms
+}
+
+func createLabelSelector(_label_selectors []interface{}) *unversioned.LabelSelector {
+	if len(_label_selectors) == 0 {
+		return nil
+	} else {
+		_label_selector := _label_selectors[0].(map[string]interface{})
+		labelSelector := &unversioned.LabelSelector{}
+
+		if val, ok := _label_selector["match_labels"]; ok {
+			matchLabels := val.(map[string]interface{})
+			labelSelector.MatchLabels = make(map[string]string, len(matchLabels))
+			for k, v := range matchLabels {
+				labelSelector.MatchLabels[k] = v.(string)
+			}
+		}
+
+		if val, ok := _label_selector["match_expression"]; ok {
+			labelSelector.MatchExpressions = createLabelSelectorRequirements(val.([]interface{}))
+		}
+
+		return labelSelector
+	}
+}
+
+func createLabelSelectorRequirements(_requirements []interface{}) []unversioned.LabelSelectorRequirement {
+	requirements := make([]unversioned.LabelSelectorRequirement, len(_requirements))
+	for i, v := range _requirements {
+		_requirement := v.(map[string]interface{})
+		requirement := unversioned.LabelSelectorRequirement{}
+
+		requirement.Key = _requirement["key"].(string)
+		requirement.Operator = unversioned.LabelSelectorOperator(_requirement["operator"].(string))
+
+		if val, ok := _requirement["values"]; ok {
+			requirement.Values = createStringList(val.([]interface{}))
+		}
+
+		requirements[i] = requirement
+	}
+	return requirements
+}
+
+// createTolerations converts the pod's `toleration` blocks, letting it
+// schedule onto nodes whose taints would otherwise repel it.
+func createTolerations(_tolerations []interface{}) []api.Toleration {
+	tolerations := make([]api.Toleration, len(_tolerations))
+	for i, v := range _tolerations {
+		_toleration := v.(map[string]interface{})
+		toleration := api.Toleration{}
+
+		if val, ok := _toleration["key"]; ok {
+			toleration.Key = val.(string)
+		}
+
+		if val, ok := _toleration["operator"]; ok {
+			toleration.Operator = api.TolerationOperator(val.(string))
+		}
+
+		if val, ok := _toleration["value"]; ok {
+			toleration.Value = val.(string)
+		}
+
+		if val, ok := _toleration["effect"]; ok {
+			toleration.Effect = api.TaintEffect(val.(string))
+		}
+
+		if val, ok := _toleration["toleration_seconds"]; ok {
+			v := int64(val.(int))
+			toleration.TolerationSeconds = &v
+		}
+
+		tolerations[i] = toleration
+	}
+	return tolerations
+}
@@ -0,0 +1,902 @@
+package kubernetes
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+// This file mirrors crud_pod_schemas.go one-to-one: every create* function
+// there has a flatten* counterpart here that converts the api.* value it
+// produced back into the []interface{}/map[string]interface{} shape Read
+// needs to populate state from the API server. Keeping the two files
+// parallel makes it easy to tell whether a field is missing a round trip.
+
+func flattenStringList(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
+
+func flattenVolumes(volumes []api.Volume) []interface{} {
+	result := make([]interface{}, len(volumes))
+	for i, volume := range volumes {
+		result[i] = map[string]interface{}{
+			"name":          volume.Name,
+			"volume_source": flattenVolumeSource(&volume.VolumeSource),
+		}
+	}
+	return result
+}
+
+func flattenVolumeSource(volumeSource *api.VolumeSource) []interface{} {
+	if volumeSource == nil {
+		return []interface{}{}
+	}
+
+	result := map[string]interface{}{}
+
+	if volumeSource.HostPath != nil {
+		result["host_path"] = flattenHostPathVolumeSource(volumeSource.HostPath)
+	}
+	if volumeSource.EmptyDir != nil {
+		result["empty_dir"] = flattenEmptyDirVolumeSource(volumeSource.EmptyDir)
+	}
+	if volumeSource.GCEPersistentDisk != nil {
+		result["gce_persistent_disk"] = flattenGcePersistentDiskVolumeSource(volumeSource.GCEPersistentDisk)
+	}
+	if volumeSource.AWSElasticBlockStore != nil {
+		result["aws_elastic_block_store"] = flattenAwsElasticBlockStoreVolumeSource(volumeSource.AWSElasticBlockStore)
+	}
+	if volumeSource.GitRepo != nil {
+		result["git_repo"] = flattenGitRepoVolumeSource(volumeSource.GitRepo)
+	}
+	if volumeSource.Secret != nil {
+		result["secret"] = flattenSecretVolumeSource(volumeSource.Secret)
+	}
+	if volumeSource.NFS != nil {
+		result["nfs"] = flattenNfsVolumeSource(volumeSource.NFS)
+	}
+	if volumeSource.ISCSI != nil {
+		result["iscsi"] = flattenIscsiVolumeSource(volumeSource.ISCSI)
+	}
+	if volumeSource.Glusterfs != nil {
+		result["gluster_fs"] = flattenGlusterfsVolumeSource(volumeSource.Glusterfs)
+	}
+	if volumeSource.PersistentVolumeClaim != nil {
+		result["persistent_volume_claim"] = flattenPersistentVolumeClaimVolumeSource(volumeSource.PersistentVolumeClaim)
+	}
+	if volumeSource.RBD != nil {
+		result["rbd"] = flattenRbdVolumeSource(volumeSource.RBD)
+	}
+	if volumeSource.Cinder != nil {
+		result["cinder"] = flattenCinderVolumeSource(volumeSource.Cinder)
+	}
+	if volumeSource.CephFS != nil {
+		result["cephfs"] = flattenCephFsVolumeSource(volumeSource.CephFS)
+	}
+	if volumeSource.Flocker != nil {
+		result["flocker"] = flattenFlockerVolumeSource(volumeSource.Flocker)
+	}
+	if volumeSource.DownwardAPI != nil {
+		result["downward_api"] = flattenDownwardApiVolumeSource(volumeSource.DownwardAPI)
+	}
+	if volumeSource.FC != nil {
+		result["fc"] = flattenFcVolumeSource(volumeSource.FC)
+	}
+	if volumeSource.ConfigMap != nil {
+		result["config_map"] = flattenConfigMapVolumeSource(volumeSource.ConfigMap)
+	}
+	if volumeSource.Projected != nil {
+		result["projected"] = flattenProjectedVolumeSource(volumeSource.Projected)
+	}
+	if volumeSource.AzureDisk != nil {
+		result["azure_disk"] = flattenAzureDiskVolumeSource(volumeSource.AzureDisk)
+	}
+	if volumeSource.AzureFile != nil {
+		result["azure_file"] = flattenAzureFileVolumeSource(volumeSource.AzureFile)
+	}
+	if volumeSource.FlexVolume != nil {
+		result["flex_volume"] = flattenFlexVolumeSource(volumeSource.FlexVolume)
+	}
+	if volumeSource.CSI != nil {
+		result["csi"] = flattenCsiVolumeSource(volumeSource.CSI)
+	}
+
+	return []interface{}{result}
+}
+
+func flattenLocalObjectReference(ref *api.LocalObjectReference) []interface{} {
+	if ref == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"name": ref.Name,
+	}}
+}
+
+func flattenHostPathVolumeSource(hostPath *api.HostPathVolumeSource) []interface{} {
+	if hostPath == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"path": hostPath.Path,
+	}}
+}
+
+func flattenEmptyDirVolumeSource(emptyDir *api.EmptyDirVolumeSource) []interface{} {
+	if emptyDir == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"medium": string(emptyDir.Medium),
+	}}
+}
+
+func flattenGcePersistentDiskVolumeSource(disk *api.GCEPersistentDiskVolumeSource) []interface{} {
+	if disk == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"pd_name":   disk.PDName,
+		"fs_type":   disk.FSType,
+		"partition": disk.Partition,
+		"read_only": disk.ReadOnly,
+	}}
+}
+
+func flattenAwsElasticBlockStoreVolumeSource(ebs *api.AWSElasticBlockStoreVolumeSource) []interface{} {
+	if ebs == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"volume_id": ebs.VolumeID,
+		"fs_type":   ebs.FSType,
+		"partition": ebs.Partition,
+		"read_only": ebs.ReadOnly,
+	}}
+}
+
+func flattenGitRepoVolumeSource(gitRepo *api.GitRepoVolumeSource) []interface{} {
+	if gitRepo == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"repository": gitRepo.Repository,
+		"revision":   gitRepo.Revision,
+	}}
+}
+
+func flattenSecretVolumeSource(secret *api.SecretVolumeSource) []interface{} {
+	if secret == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"secret_name": secret.SecretName,
+	}}
+}
+
+func flattenNfsVolumeSource(nfs *api.NFSVolumeSource) []interface{} {
+	if nfs == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"server":    nfs.Server,
+		"path":      nfs.Path,
+		"read_only": nfs.ReadOnly,
+	}}
+}
+
+func flattenIscsiVolumeSource(iscsi *api.ISCSIVolumeSource) []interface{} {
+	if iscsi == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"target_portal": iscsi.TargetPortal,
+		"iqn":           iscsi.IQN,
+		"lun":           iscsi.Lun,
+		"fs_type":       iscsi.FSType,
+		"read_only":     iscsi.ReadOnly,
+	}}
+}
+
+func flattenGlusterfsVolumeSource(glusterfs *api.GlusterfsVolumeSource) []interface{} {
+	if glusterfs == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"endpoints_name": glusterfs.EndpointsName,
+		"path":           glusterfs.Path,
+		"read_only":      glusterfs.ReadOnly,
+	}}
+}
+
+func flattenPersistentVolumeClaimVolumeSource(pvc *api.PersistentVolumeClaimVolumeSource) []interface{} {
+	if pvc == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"claim_name": pvc.ClaimName,
+		"read_only":  pvc.ReadOnly,
+	}}
+}
+
+func flattenRbdVolumeSource(rbd *api.RBDVolumeSource) []interface{} {
+	if rbd == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"ceph_monitors": flattenStringList(rbd.CephMonitors),
+		"rbd_image":     rbd.RBDImage,
+		"fs_type":       rbd.FSType,
+		"rbd_pool":      rbd.RBDPool,
+		"rados_user":    rbd.RadosUser,
+		"keyring":       rbd.Keyring,
+		"secret_ref":    flattenLocalObjectReference(rbd.SecretRef),
+		"read_only":     rbd.ReadOnly,
+	}}
+}
+
+func flattenCinderVolumeSource(cinder *api.CinderVolumeSource) []interface{} {
+	if cinder == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"volume_id": cinder.VolumeID,
+		"fs_type":   cinder.FSType,
+		"read_only": cinder.ReadOnly,
+	}}
+}
+
+func flattenCephFsVolumeSource(cephFs *api.CephFSVolumeSource) []interface{} {
+	if cephFs == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"monitors":    flattenStringList(cephFs.Monitors),
+		"user":        cephFs.User,
+		"secret_file": cephFs.SecretFile,
+		"secret_ref":  flattenLocalObjectReference(cephFs.SecretRef),
+		"read_only":   cephFs.ReadOnly,
+		"path":        cephFs.Path,
+	}}
+}
+
+func flattenFlockerVolumeSource(flocker *api.FlockerVolumeSource) []interface{} {
+	if flocker == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"dataset_name": flocker.DatasetName,
+		"dataset_uuid": flocker.DatasetUUID,
+	}}
+}
+
+func flattenDownwardApiVolumeSource(downwardApi *api.DownwardAPIVolumeSource) []interface{} {
+	if downwardApi == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"items": flattenDownwardApiVolumeFiles(downwardApi.Items),
+	}}
+}
+
+func flattenDownwardApiVolumeFiles(volumeFiles []api.DownwardAPIVolumeFile) []interface{} {
+	result := make([]interface{}, len(volumeFiles))
+	for i, volumeFile := range volumeFiles {
+		result[i] = map[string]interface{}{
+			"path":      volumeFile.Path,
+			"field_ref": flattenObjectFieldSelector(&volumeFile.FieldRef),
+		}
+	}
+	return result
+}
+
+func flattenFcVolumeSource(fc *api.FCVolumeSource) []interface{} {
+	if fc == nil {
+		return []interface{}{}
+	}
+	result := map[string]interface{}{
+		"target_wwns": flattenStringList(fc.TargetWWNs),
+		"fs_type":     fc.FSType,
+		"read_only":   fc.ReadOnly,
+	}
+	if fc.Lun != nil {
+		result["lun"] = *fc.Lun
+	}
+	return []interface{}{result}
+}
+
+func flattenConfigMapVolumeSource(configMap *api.ConfigMapVolumeSource) []interface{} {
+	if configMap == nil {
+		return []interface{}{}
+	}
+	result := map[string]interface{}{
+		"name":  configMap.Name,
+		"items": flattenKeyToPaths(configMap.Items),
+	}
+	if configMap.DefaultMode != nil {
+		result["default_mode"] = int(*configMap.DefaultMode)
+	}
+	if configMap.Optional != nil {
+		result["optional"] = *configMap.Optional
+	}
+	return []interface{}{result}
+}
+
+func flattenKeyToPaths(items []api.KeyToPath) []interface{} {
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		m := map[string]interface{}{
+			"key":  item.Key,
+			"path": item.Path,
+		}
+		if item.Mode != nil {
+			m["mode"] = int(*item.Mode)
+		}
+		result[i] = m
+	}
+	return result
+}
+
+func flattenProjectedVolumeSource(projected *api.ProjectedVolumeSource) []interface{} {
+	if projected == nil {
+		return []interface{}{}
+	}
+	result := map[string]interface{}{
+		"sources": flattenVolumeProjections(projected.Sources),
+	}
+	if projected.DefaultMode != nil {
+		result["default_mode"] = int(*projected.DefaultMode)
+	}
+	return []interface{}{result}
+}
+
+func flattenVolumeProjections(sources []api.VolumeProjection) []interface{} {
+	result := make([]interface{}, len(sources))
+	for i, source := range sources {
+		m := map[string]interface{}{}
+		if source.Secret != nil {
+			m["secret"] = flattenSecretProjection(source.Secret)
+		}
+		if source.ConfigMap != nil {
+			m["config_map"] = flattenConfigMapProjection(source.ConfigMap)
+		}
+		if source.DownwardAPI != nil {
+			m["downward_api"] = flattenDownwardApiProjection(source.DownwardAPI)
+		}
+		if source.ServiceAccountToken != nil {
+			m["service_account_token"] = flattenServiceAccountTokenProjection(source.ServiceAccountToken)
+		}
+		result[i] = m
+	}
+	return result
+}
+
+func flattenSecretProjection(secret *api.SecretProjection) []interface{} {
+	if secret == nil {
+		return []interface{}{}
+	}
+	result := map[string]interface{}{
+		"name":  secret.Name,
+		"items": flattenKeyToPaths(secret.Items),
+	}
+	if secret.Optional != nil {
+		result["optional"] = *secret.Optional
+	}
+	return []interface{}{result}
+}
+
+func flattenConfigMapProjection(configMap *api.ConfigMapProjection) []interface{} {
+	if configMap == nil {
+		return []interface{}{}
+	}
+	result := map[string]interface{}{
+		"name":  configMap.Name,
+		"items": flattenKeyToPaths(configMap.Items),
+	}
+	if configMap.Optional != nil {
+		result["optional"] = *configMap.Optional
+	}
+	return []interface{}{result}
+}
+
+func flattenDownwardApiProjection(downwardApi *api.DownwardAPIProjection) []interface{} {
+	if downwardApi == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"items": flattenDownwardApiVolumeFiles(downwardApi.Items),
+	}}
+}
+
+func flattenServiceAccountTokenProjection(token *api.ServiceAccountTokenProjection) []interface{} {
+	if token == nil {
+		return []interface{}{}
+	}
+	result := map[string]interface{}{
+		"audience": token.Audience,
+		"path":     token.Path,
+	}
+	if token.ExpirationSeconds != nil {
+		result["expiration_seconds"] = int(*token.ExpirationSeconds)
+	}
+	return []interface{}{result}
+}
+
+func flattenAzureDiskVolumeSource(azureDisk *api.AzureDiskVolumeSource) []interface{} {
+	if azureDisk == nil {
+		return []interface{}{}
+	}
+	result := map[string]interface{}{
+		"disk_name":     azureDisk.DiskName,
+		"data_disk_uri": azureDisk.DataDiskURI,
+	}
+	if azureDisk.CachingMode != nil {
+		result["caching_mode"] = string(*azureDisk.CachingMode)
+	}
+	if azureDisk.FSType != nil {
+		result["fs_type"] = *azureDisk.FSType
+	}
+	if azureDisk.ReadOnly != nil {
+		result["read_only"] = *azureDisk.ReadOnly
+	}
+	return []interface{}{result}
+}
+
+func flattenAzureFileVolumeSource(azureFile *api.AzureFileVolumeSource) []interface{} {
+	if azureFile == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"secret_name": azureFile.SecretName,
+		"share_name":  azureFile.ShareName,
+		"read_only":   azureFile.ReadOnly,
+	}}
+}
+
+func flattenFlexVolumeSource(flexVolume *api.FlexVolumeSource) []interface{} {
+	if flexVolume == nil {
+		return []interface{}{}
+	}
+	options := make(map[string]interface{}, len(flexVolume.Options))
+	for k, v := range flexVolume.Options {
+		options[k] = v
+	}
+	return []interface{}{map[string]interface{}{
+		"driver":     flexVolume.Driver,
+		"fs_type":    flexVolume.FSType,
+		"secret_ref": flattenLocalObjectReference(flexVolume.SecretRef),
+		"read_only":  flexVolume.ReadOnly,
+		"options":    options,
+	}}
+}
+
+func flattenCsiVolumeSource(csi *api.CSIVolumeSource) []interface{} {
+	if csi == nil {
+		return []interface{}{}
+	}
+	attributes := make(map[string]interface{}, len(csi.VolumeAttributes))
+	for k, v := range csi.VolumeAttributes {
+		attributes[k] = v
+	}
+	result := map[string]interface{}{
+		"driver":                  csi.Driver,
+		"volume_handle":           csi.VolumeHandle,
+		"volume_attributes":       attributes,
+		"node_publish_secret_ref": flattenLocalObjectReference(csi.NodePublishSecretRef),
+	}
+	if csi.ReadOnly != nil {
+		result["read_only"] = *csi.ReadOnly
+	}
+	if csi.FSType != nil {
+		result["fs_type"] = *csi.FSType
+	}
+	return []interface{}{result}
+}
+
+func flattenContainers(containers []api.Container) []interface{} {
+	result := make([]interface{}, len(containers))
+	for i, container := range containers {
+		m := map[string]interface{}{
+			"name":                     container.Name,
+			"image":                    container.Image,
+			"command":                  flattenStringList(container.Command),
+			"args":                     flattenStringList(container.Args),
+			"working_dir":              container.WorkingDir,
+			"container_port":           flattenContainerPorts(container.Ports),
+			"env":                      flattenEnvVars(container.Env),
+			"resources":                flattenResourceRequirements(&container.Resources),
+			"volume_mount":             flattenVolumeMounts(container.VolumeMounts),
+			"liveness_probe":           flattenProbe(container.LivenessProbe),
+			"readiness_probe":          flattenProbe(container.ReadinessProbe),
+			"lifecycle":                flattenLifecycle(container.Lifecycle),
+			"termination_message_path": container.TerminationMessagePath,
+			"image_pull_policy":        string(container.ImagePullPolicy),
+			"security_context":         flattenSecurityContext(container.SecurityContext),
+			"stdin":                    container.Stdin,
+			"tty":                      container.TTY,
+		}
+		result[i] = m
+	}
+	return result
+}
+
+func flattenContainerPorts(ports []api.ContainerPort) []interface{} {
+	result := make([]interface{}, len(ports))
+	for i, port := range ports {
+		result[i] = map[string]interface{}{
+			"name":           port.Name,
+			"host_port":      port.HostPort,
+			"container_port": port.ContainerPort,
+			"protocol":       string(port.Protocol),
+			"host_ip":        port.HostIP,
+		}
+	}
+	return result
+}
+
+func flattenEnvVars(envVars []api.EnvVar) []interface{} {
+	result := make([]interface{}, len(envVars))
+	for i, envVar := range envVars {
+		result[i] = map[string]interface{}{
+			"name":       envVar.Name,
+			"value":      envVar.Value,
+			"value_from": flattenEnvVarSource(envVar.ValueFrom),
+		}
+	}
+	return result
+}
+
+func flattenEnvVarSource(envVarSource *api.EnvVarSource) []interface{} {
+	if envVarSource == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"field_ref": flattenObjectFieldSelector(envVarSource.FieldRef),
+	}}
+}
+
+func flattenObjectFieldSelector(fieldRef *api.ObjectFieldSelector) []interface{} {
+	if fieldRef == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"api_version": fieldRef.APIVersion,
+		"field_path":  fieldRef.FieldPath,
+	}}
+}
+
+func flattenResourceRequirements(resources *api.ResourceRequirements) []interface{} {
+	if resources == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"limits":   flattenResourceList(resources.Limits),
+		"requests": flattenResourceList(resources.Requests),
+	}}
+}
+
+func flattenResourceList(resourceList map[api.ResourceName]resource.Quantity) map[string]interface{} {
+	result := make(map[string]interface{}, len(resourceList))
+	for k, v := range resourceList {
+		result[string(k)] = v.String()
+	}
+	return result
+}
+
+func flattenVolumeMounts(volumeMounts []api.VolumeMount) []interface{} {
+	result := make([]interface{}, len(volumeMounts))
+	for i, volumeMount := range volumeMounts {
+		result[i] = map[string]interface{}{
+			"name":       volumeMount.Name,
+			"read_only":  volumeMount.ReadOnly,
+			"mount_path": volumeMount.MountPath,
+		}
+	}
+	return result
+}
+
+func flattenProbe(probe *api.Probe) []interface{} {
+	if probe == nil {
+		return []interface{}{}
+	}
+	handler := &api.Handler{
+		Exec:      probe.Exec,
+		HTTPGet:   probe.HTTPGet,
+		TCPSocket: probe.TCPSocket,
+	}
+	return []interface{}{map[string]interface{}{
+		"handler":               flattenHandler(handler),
+		"initial_delay_seconds": int(probe.InitialDelaySeconds),
+		"timeout_seconds":       int(probe.TimeoutSeconds),
+	}}
+}
+
+func flattenHandler(handler *api.Handler) []interface{} {
+	if handler == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"exec":       flattenExecAction(handler.Exec),
+		"http_get":   flattenHttpGetAction(handler.HTTPGet),
+		"tcp_socket": flattenTcpSocketAction(handler.TCPSocket),
+	}}
+}
+
+func flattenExecAction(exec *api.ExecAction) []interface{} {
+	if exec == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"command": flattenStringList(exec.Command),
+	}}
+}
+
+func flattenHttpGetAction(httpGet *api.HTTPGetAction) []interface{} {
+	if httpGet == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"port":   httpGet.Port.IntValue(),
+		"path":   httpGet.Path,
+		"host":   httpGet.Host,
+		"scheme": string(httpGet.Scheme),
+	}}
+}
+
+func flattenTcpSocketAction(tcpSocket *api.TCPSocketAction) []interface{} {
+	if tcpSocket == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"port": tcpSocket.Port.IntValue(),
+	}}
+}
+
+func flattenLifecycle(lifecycle *api.Lifecycle) []interface{} {
+	if lifecycle == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"post_start": flattenHandler(lifecycle.PostStart),
+		"pre_stop":   flattenHandler(lifecycle.PreStop),
+	}}
+}
+
+func flattenSecurityContext(securityContext *api.SecurityContext) []interface{} {
+	if securityContext == nil {
+		return []interface{}{}
+	}
+
+	result := map[string]interface{}{
+		"capabilities":     flattenCapabilities(securityContext.Capabilities),
+		"se_linux_options": flattenSeLinuxOptions(securityContext.SELinuxOptions),
+		"run_as_non_root":  securityContext.RunAsNonRoot,
+	}
+	if securityContext.Privileged != nil {
+		result["privileged"] = *securityContext.Privileged
+	}
+	if securityContext.RunAsUser != nil {
+		result["run_as_user"] = int(*securityContext.RunAsUser)
+	}
+
+	return []interface{}{result}
+}
+
+func flattenCapabilities(capabilities *api.Capabilities) []interface{} {
+	if capabilities == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"add":  flattenCapabilityList(capabilities.Add),
+		"drop": flattenCapabilityList(capabilities.Drop),
+	}}
+}
+
+func flattenCapabilityList(capabilities []api.Capability) []interface{} {
+	result := make([]interface{}, len(capabilities))
+	for i, capability := range capabilities {
+		result[i] = string(capability)
+	}
+	return result
+}
+
+func flattenSeLinuxOptions(seLinuxOptions *api.SELinuxOptions) []interface{} {
+	if seLinuxOptions == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"user":  seLinuxOptions.User,
+		"role":  seLinuxOptions.Role,
+		"type":  seLinuxOptions.Type,
+		"level": seLinuxOptions.Level,
+	}}
+}
+
+func flattenInt64List(values []int64) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = int(v)
+	}
+	return result
+}
+
+func flattenPodSecurityContext(securityContext *api.PodSecurityContext) []interface{} {
+	if securityContext == nil {
+		return []interface{}{}
+	}
+
+	result := map[string]interface{}{
+		"se_linux_options":    flattenSeLinuxOptions(securityContext.SELinuxOptions),
+		"supplemental_groups": flattenInt64List(securityContext.SupplementalGroups),
+	}
+	if securityContext.RunAsUser != nil {
+		result["run_as_user"] = int(*securityContext.RunAsUser)
+	}
+	if securityContext.RunAsNonRoot != nil {
+		result["run_as_non_root"] = *securityContext.RunAsNonRoot
+	}
+	if securityContext.FSGroup != nil {
+		result["fs_group"] = int(*securityContext.FSGroup)
+	}
+
+	return []interface{}{result}
+}
+
+func flattenAffinity(affinity *api.Affinity) []interface{} {
+	if affinity == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"node_affinity":     flattenNodeAffinity(affinity.NodeAffinity),
+		"pod_affinity":      flattenPodAffinity(affinity.PodAffinity),
+		"pod_anti_affinity": flattenPodAntiAffinity(affinity.PodAntiAffinity),
+	}}
+}
+
+func flattenNodeAffinity(nodeAffinity *api.NodeAffinity) []interface{} {
+	if nodeAffinity == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"required_during_scheduling_ignored_during_execution":  flattenNodeSelector(nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution),
+		"preferred_during_scheduling_ignored_during_execution": flattenPreferredSchedulingTerms(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution),
+	}}
+}
+
+func flattenNodeSelector(nodeSelector *api.NodeSelector) []interface{} {
+	if nodeSelector == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"node_selector_term": flattenNodeSelectorTerms(nodeSelector.NodeSelectorTerms),
+	}}
+}
+
+func flattenNodeSelectorTerms(terms []api.NodeSelectorTerm) []interface{} {
+	result := make([]interface{}, len(terms))
+	for i, term := range terms {
+		result[i] = map[string]interface{}{
+			"match_expression": flattenNodeSelectorRequirements(term.MatchExpressions),
+		}
+	}
+	return result
+}
+
+func flattenNodeSelectorRequirements(requirements []api.NodeSelectorRequirement) []interface{} {
+	result := make([]interface{}, len(requirements))
+	for i, requirement := range requirements {
+		result[i] = map[string]interface{}{
+			"key":      requirement.Key,
+			"operator": string(requirement.Operator),
+			"values":   flattenStringList(requirement.Values),
+		}
+	}
+	return result
+}
+
+func flattenPreferredSchedulingTerms(terms []api.PreferredSchedulingTerm) []interface{} {
+	result := make([]interface{}, len(terms))
+	for i, term := range terms {
+		result[i] = map[string]interface{}{
+			"weight":     int(term.Weight),
+			"preference": flattenNodeSelectorTerms([]api.NodeSelectorTerm{term.Preference}),
+		}
+	}
+	return result
+}
+
+func flattenPodAffinity(podAffinity *api.PodAffinity) []interface{} {
+	if podAffinity == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"required_during_scheduling_ignored_during_execution":  flattenPodAffinityTerms(podAffinity.RequiredDuringSchedulingIgnoredDuringExecution),
+		"preferred_during_scheduling_ignored_during_execution": flattenWeightedPodAffinityTerms(podAffinity.PreferredDuringSchedulingIgnoredDuringExecution),
+	}}
+}
+
+func flattenPodAntiAffinity(podAntiAffinity *api.PodAntiAffinity) []interface{} {
+	if podAntiAffinity == nil {
+		return []interface{}{}
+	}
+	return []interface{}{map[string]interface{}{
+		"required_during_scheduling_ignored_during_execution":  flattenPodAffinityTerms(podAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution),
+		"preferred_during_scheduling_ignored_during_execution": flattenWeightedPodAffinityTerms(podAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution),
+	}}
+}
+
+func flattenPodAffinityTerms(terms []api.PodAffinityTerm) []interface{} {
+	result := make([]interface{}, len(terms))
+	for i, term := range terms {
+		result[i] = flattenPodAffinityTerm(term)
+	}
+	return result
+}
+
+func flattenPodAffinityTerm(term api.PodAffinityTerm) map[string]interface{} {
+	return map[string]interface{}{
+		"label_selector": flattenLabelSelector(term.LabelSelector),
+		"namespaces":     flattenStringList(term.Namespaces),
+		"topology_key":   term.TopologyKey,
+	}
+}
+
+func flattenWeightedPodAffinityTerms(terms []api.WeightedPodAffinityTerm) []interface{} {
+	result := make([]interface{}, len(terms))
+	for i, term := range terms {
+		result[i] = map[string]interface{}{
+			"weight":            int(term.Weight),
+			"pod_affinity_term": []interface{}{flattenPodAffinityTerm(term.PodAffinityTerm)},
+		}
+	}
+	return result
+}
+
+func flattenLabelSelector(labelSelector *unversioned.LabelSelector) []interface{} {
+	if labelSelector == nil {
+		return []interface{}{}
+	}
+
+	matchLabels := make(map[string]interface{}, len(labelSelector.MatchLabels))
+	for k, v := range labelSelector.MatchLabels {
+		matchLabels[k] = v
+	}
+
+	return []interface{}{map[string]interface{}{
+		"match_labels":     matchLabels,
+		"match_expression": flattenLabelSelectorRequirements(labelSelector.MatchExpressions),
+	}}
+}
+
+func flattenLabelSelectorRequirements(requirements []unversioned.LabelSelectorRequirement) []interface{} {
+	result := make([]interface{}, len(requirements))
+	for i, requirement := range requirements {
+		result[i] = map[string]interface{}{
+			"key":      requirement.Key,
+			"operator": string(requirement.Operator),
+			"values":   flattenStringList(requirement.Values),
+		}
+	}
+	return result
+}
+
+func flattenTolerations(tolerations []api.Toleration) []interface{} {
+	result := make([]interface{}, len(tolerations))
+	for i, toleration := range tolerations {
+		t := map[string]interface{}{
+			"key":      toleration.Key,
+			"operator": string(toleration.Operator),
+			"value":    toleration.Value,
+			"effect":   string(toleration.Effect),
+		}
+		if toleration.TolerationSeconds != nil {
+			t["toleration_seconds"] = int(*toleration.TolerationSeconds)
+		}
+		result[i] = t
+	}
+	return result
+}
@@ -1,7 +1,10 @@
 package nsone
 
 import (
+	"fmt"
+
 	"github.com/hashicorp/terraform/helper/schema"
+	"gopkg.in/ns1/ns1-go.v2/rest"
 	"gopkg.in/ns1/ns1-go.v2/rest/model/account"
 )
 
@@ -84,6 +87,39 @@ func addPermsSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
 		Type:     schema.TypeBool,
 		Optional: true,
 	}
+	s["security_manage_global_2fa"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+	}
+	s["security_manage_active_directory"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+	}
+	s["ip_whitelist"] = &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Elem:     &schema.Schema{Type: schema.TypeString},
+	}
+	s["ip_whitelist_strict"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+	}
+	s["dhcp_manage_dhcp"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+	}
+	s["dhcp_view_dhcp"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+	}
+	s["ipam_manage_ipam"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+	}
+	s["ipam_view_ipam"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+	}
 	return s
 }
 
@@ -107,4 +143,135 @@ func permissionsToResourceData(d *schema.ResourceData, permissions account.Permi
 	d.Set("monitoring_manage_lists", permissions.Monitoring.ManageLists)
 	d.Set("monitoring_manage_jobs", permissions.Monitoring.ManageJobs)
 	d.Set("monitoring_view_jobs", permissions.Monitoring.ViewJobs)
+	d.Set("security_manage_global_2fa", permissions.Security.ManageGlobal2FA)
+	d.Set("security_manage_active_directory", permissions.Security.ManageActiveDirectory)
+	d.Set("ip_whitelist", permissions.Security.IPWhitelist)
+	d.Set("ip_whitelist_strict", permissions.Security.IPWhitelistStrict)
+	d.Set("dhcp_manage_dhcp", permissions.DHCP.ManageDHCP)
+	d.Set("dhcp_view_dhcp", permissions.DHCP.ViewDHCP)
+	d.Set("ipam_manage_ipam", permissions.IPAM.ManageIPAM)
+	d.Set("ipam_view_ipam", permissions.IPAM.ViewIPAM)
+}
+
+// teamIDsSchema is meant to be added (under the key "team_ids") by any
+// resource that embeds addPermsSchema's fields, such as nsone_user or
+// nsone_apikey, so its permissions can be inherited from one or more
+// teams instead of set directly. Those resources don't exist in this
+// trimmed snapshot of the provider (only permissions.go and its test are
+// present in this package), so nothing here wires team_ids into a real
+// resource's Create/Update; resolveTeamPermissions below is what such a
+// resource's Create/Update would call with its own *rest.Client.
+func teamIDsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Elem:     &schema.Schema{Type: schema.TypeString},
+	}
+}
+
+// permBoolField describes one of addPermsSchema's boolean permission
+// fields: its config key, and how to reach the corresponding field on an
+// account.PermissionsMap so resolveTeamPermissions can read and OR it
+// without a long hand-written switch per field.
+type permBoolField struct {
+	key string
+	ptr func(pm *account.PermissionsMap) *bool
+}
+
+var permBoolFields = []permBoolField{
+	{"dns_view_zones", func(pm *account.PermissionsMap) *bool { return &pm.DNS.ViewZones }},
+	{"dns_manage_zones", func(pm *account.PermissionsMap) *bool { return &pm.DNS.ManageZones }},
+	{"dns_zones_allow_by_default", func(pm *account.PermissionsMap) *bool { return &pm.DNS.ZonesAllowByDefault }},
+	{"data_push_to_datafeeds", func(pm *account.PermissionsMap) *bool { return &pm.Data.PushToDatafeeds }},
+	{"data_manage_datasources", func(pm *account.PermissionsMap) *bool { return &pm.Data.ManageDatasources }},
+	{"data_manage_datafeeds", func(pm *account.PermissionsMap) *bool { return &pm.Data.ManageDatafeeds }},
+	{"account_manage_users", func(pm *account.PermissionsMap) *bool { return &pm.Account.ManageUsers }},
+	{"account_manage_payment_methods", func(pm *account.PermissionsMap) *bool { return &pm.Account.ManagePaymentMethods }},
+	{"account_manage_plan", func(pm *account.PermissionsMap) *bool { return &pm.Account.ManagePlan }},
+	{"account_manage_teams", func(pm *account.PermissionsMap) *bool { return &pm.Account.ManageTeams }},
+	{"account_manage_apikeys", func(pm *account.PermissionsMap) *bool { return &pm.Account.ManageApikeys }},
+	{"account_manage_account_settings", func(pm *account.PermissionsMap) *bool { return &pm.Account.ManageAccountSettings }},
+	{"account_view_activity_log", func(pm *account.PermissionsMap) *bool { return &pm.Account.ViewActivityLog }},
+	{"account_view_invoices", func(pm *account.PermissionsMap) *bool { return &pm.Account.ViewInvoices }},
+	{"monitoring_manage_lists", func(pm *account.PermissionsMap) *bool { return &pm.Monitoring.ManageLists }},
+	{"monitoring_manage_jobs", func(pm *account.PermissionsMap) *bool { return &pm.Monitoring.ManageJobs }},
+	{"monitoring_view_jobs", func(pm *account.PermissionsMap) *bool { return &pm.Monitoring.ViewJobs }},
+	{"security_manage_global_2fa", func(pm *account.PermissionsMap) *bool { return &pm.Security.ManageGlobal2FA }},
+	{"security_manage_active_directory", func(pm *account.PermissionsMap) *bool { return &pm.Security.ManageActiveDirectory }},
+	{"ip_whitelist_strict", func(pm *account.PermissionsMap) *bool { return &pm.Security.IPWhitelistStrict }},
+	{"dhcp_manage_dhcp", func(pm *account.PermissionsMap) *bool { return &pm.DHCP.ManageDHCP }},
+	{"dhcp_view_dhcp", func(pm *account.PermissionsMap) *bool { return &pm.DHCP.ViewDHCP }},
+	{"ipam_manage_ipam", func(pm *account.PermissionsMap) *bool { return &pm.IPAM.ManageIPAM }},
+	{"ipam_view_ipam", func(pm *account.PermissionsMap) *bool { return &pm.IPAM.ViewIPAM }},
+}
+
+// resolveTeamPermissions computes the effective PermissionsMap for a
+// resource that sets team_ids: the booleans and ip_whitelist explicitly
+// present in d, unioned with whatever every named team grants. A
+// permission explicitly set to false in d that a named team grants is an
+// error rather than a silent override in either direction, since neither
+// "the explicit value wins" nor "the team wins" is what a user who wrote
+// both would expect.
+func resolveTeamPermissions(client *rest.Client, teamIDs []string, d *schema.ResourceData) (account.PermissionsMap, error) {
+	var merged account.PermissionsMap
+
+	for _, field := range permBoolFields {
+		if v, ok := d.GetOk(field.key); ok {
+			*field.ptr(&merged) = v.(bool)
+		}
+	}
+	merged.Security.IPWhitelist = expandStringList(d.Get("ip_whitelist").([]interface{}))
+
+	for _, teamID := range teamIDs {
+		team, _, err := client.Teams.Get(teamID)
+		if err != nil {
+			return account.PermissionsMap{}, fmt.Errorf("error fetching team %q for permission inheritance: %s", teamID, err)
+		}
+
+		for _, field := range permBoolFields {
+			teamVal := *field.ptr(&team.Permissions)
+			if !teamVal {
+				continue
+			}
+			// GetOk alone can't tell "explicitly false" from "unset", since
+			// both read back the bool zero value; GetOkExists is the only
+			// way to catch a user-written `= false` that disagrees with
+			// what the team grants.
+			if explicit, ok := d.GetOkExists(field.key); ok && !explicit.(bool) {
+				return account.PermissionsMap{}, fmt.Errorf(
+					"%s is explicitly set to false but team %q grants it; remove the explicit override or drop the team from team_ids to resolve the conflict",
+					field.key, teamID)
+			}
+			*field.ptr(&merged) = true
+		}
+		merged.Security.IPWhitelist = unionStrings(merged.Security.IPWhitelist, team.Permissions.Security.IPWhitelist)
+	}
+
+	return merged, nil
+}
+
+func expandStringList(raw []interface{}) []string {
+	out := make([]string, len(raw))
+	for i, v := range raw {
+		out[i] = v.(string)
+	}
+	return out
+}
+
+func unionStrings(a, b []string) []string {
+	seen := make(map[string]bool, len(a))
+	out := make([]string, 0, len(a)+len(b))
+	for _, s := range a {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	for _, s := range b {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
 }
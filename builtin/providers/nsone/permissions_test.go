@@ -0,0 +1,102 @@
+package nsone
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"gopkg.in/ns1/ns1-go.v2/rest"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/account"
+)
+
+// testTeamServer fakes just enough of the NS1 account API
+// (GET /account/teams/{id}) for resolveTeamPermissions' client.Teams.Get
+// calls, since this package has no acceptance-test harness (no provider.go
+// or NS1 credentials wiring in this trimmed snapshot) to run a real
+// client against.
+func testTeamServer(teams map[string]account.PermissionsMap) (*rest.Client, func()) {
+	mux := http.NewServeMux()
+	for id, perms := range teams {
+		team := account.Team{ID: id, Permissions: perms}
+		mux.HandleFunc("/account/teams/"+id, func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(team)
+		})
+	}
+	ts := httptest.NewServer(mux)
+
+	client := rest.NewClient(ts.Client(), rest.SetEndpoint(ts.URL+"/"))
+	return client, ts.Close
+}
+
+func testPermsResourceData(t *testing.T, raw map[string]interface{}) *schema.ResourceData {
+	s := addPermsSchema(map[string]*schema.Schema{})
+	s["team_ids"] = teamIDsSchema()
+	return schema.TestResourceDataRaw(t, s, raw)
+}
+
+func TestResolveTeamPermissions_Inherit(t *testing.T) {
+	client, closeServer := testTeamServer(map[string]account.PermissionsMap{
+		"team-1": {
+			Account: account.AccountPermissions{ManageUsers: true},
+			DNS:     account.DNSPermissions{ManageZones: true},
+		},
+	})
+	defer closeServer()
+
+	d := testPermsResourceData(t, map[string]interface{}{
+		"team_ids": []interface{}{"team-1"},
+	})
+
+	merged, err := resolveTeamPermissions(client, []string{"team-1"}, d)
+	if err != nil {
+		t.Fatalf("resolveTeamPermissions returned error: %s", err)
+	}
+	if !merged.Account.ManageUsers {
+		t.Error("expected account_manage_users to be inherited from team-1")
+	}
+	if !merged.DNS.ManageZones {
+		t.Error("expected dns_manage_zones to be inherited from team-1")
+	}
+}
+
+func TestResolveTeamPermissions_ExplicitConflict(t *testing.T) {
+	client, closeServer := testTeamServer(map[string]account.PermissionsMap{
+		"team-1": {
+			Account: account.AccountPermissions{ManageUsers: true},
+		},
+	})
+	defer closeServer()
+
+	d := testPermsResourceData(t, map[string]interface{}{
+		"team_ids":             []interface{}{"team-1"},
+		"account_manage_users": false,
+	})
+
+	if _, err := resolveTeamPermissions(client, []string{"team-1"}, d); err == nil {
+		t.Fatal("expected an error when account_manage_users is explicitly false but team-1 grants it")
+	}
+}
+
+func TestResolveTeamPermissions_ExplicitAgreementIsNotAConflict(t *testing.T) {
+	client, closeServer := testTeamServer(map[string]account.PermissionsMap{
+		"team-1": {
+			Account: account.AccountPermissions{ManageUsers: true},
+		},
+	})
+	defer closeServer()
+
+	d := testPermsResourceData(t, map[string]interface{}{
+		"team_ids":             []interface{}{"team-1"},
+		"account_manage_users": true,
+	})
+
+	merged, err := resolveTeamPermissions(client, []string{"team-1"}, d)
+	if err != nil {
+		t.Fatalf("resolveTeamPermissions returned error: %s", err)
+	}
+	if !merged.Account.ManageUsers {
+		t.Error("expected account_manage_users to remain true")
+	}
+}
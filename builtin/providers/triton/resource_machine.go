@@ -2,9 +2,12 @@ package triton
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"reflect"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform/helper/resource"
@@ -12,8 +15,73 @@ import (
 	"github.com/joyent/triton-go"
 )
 
+// resourceMachineFingerprintKeys maps a schema field whose value should be
+// fingerprinted rather than stored verbatim to the computed attribute that
+// holds its base64-encoded SHA-256 digest, following the GCE
+// compute-instance provider's metadata_startup_script fingerprinting so
+// that secrets rendered into user_data/user_script/cloud_config don't end
+// up persisted in plaintext in state.
+var resourceMachineFingerprintKeys = map[string]string{
+	"user_script":  "user_script_fingerprint",
+	"user_data":    "user_data_fingerprint",
+	"cloud_config": "cloud_config_fingerprint",
+}
+
+func fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// cnsTagsFromResourceData renders the "cns" block (if any) into the
+// triton.cns.services/triton.cns.disable tag entries Triton CNS reads,
+// strings.Join-ing services the same way CloudAPI expects.
+func cnsTagsFromResourceData(d *schema.ResourceData) map[string]string {
+	cnsRaw, ok := d.GetOk("cns")
+	if !ok {
+		return nil
+	}
+	cnsList := cnsRaw.([]interface{})
+	if len(cnsList) == 0 || cnsList[0] == nil {
+		return nil
+	}
+	cns := cnsList[0].(map[string]interface{})
+
+	tags := map[string]string{}
+	var services []string
+	for _, s := range cns["services"].([]interface{}) {
+		services = append(services, s.(string))
+	}
+	if len(services) > 0 {
+		tags[cnsServicesTag] = strings.Join(services, ",")
+	}
+	if cns["disable"].(bool) {
+		tags[cnsDisableTag] = "true"
+	}
+
+	return tags
+}
+
+// cnsBlockFromTags reverse-parses the triton.cns.* tags back into the "cns"
+// block's shape, so resourceMachineRead can detect drift the same way it
+// does for every other attribute.
+func cnsBlockFromTags(tags map[string]string, domainNames []string) []map[string]interface{} {
+	var services []string
+	if raw, ok := tags[cnsServicesTag]; ok && raw != "" {
+		services = strings.Split(raw, ",")
+	}
+
+	return []map[string]interface{}{
+		{
+			"services": services,
+			"disable":  tags[cnsDisableTag] == "true",
+			"fqdn":     domainNames,
+		},
+	}
+}
+
 var (
 	machineStateRunning = "running"
+	machineStateStopped = "stopped"
 	machineStateDeleted = "deleted"
 
 	machineStateChangeTimeout = 10 * time.Minute
@@ -21,6 +89,12 @@ var (
 	nicStateDeleted = "deleted"
 	nicStateRunning = "running"
 
+	volumeStateReady   = "ready"
+	volumeStateDeleted = "deleted"
+
+	cnsServicesTag = "triton.cns.services"
+	cnsDisableTag  = "triton.cns.disable"
+
 	resourceMachineMetadataKeys = map[string]string{
 		// semantics: "schema_name": "metadata_name"
 		"root_authorized_keys": "root_authorized_keys",
@@ -29,16 +103,28 @@ var (
 		"administrator_pw":     "administrator-pw",
 		"cloud_config":         "cloud-init:user-data",
 	}
+
+	// resourceMachineMetadataKeyNames is the set of Triton metadata keys
+	// already covered by resourceMachineMetadataKeys, so the "metadata"
+	// attribute only surfaces the leftovers.
+	resourceMachineMetadataKeyNames = func() map[string]struct{} {
+		names := make(map[string]struct{}, len(resourceMachineMetadataKeys))
+		for _, metadataKey := range resourceMachineMetadataKeys {
+			names[metadataKey] = struct{}{}
+		}
+		return names
+	}()
 )
 
 func resourceMachine() *schema.Resource {
 	return &schema.Resource{
-		Create:   resourceMachineCreate,
-		Exists:   resourceMachineExists,
-		Read:     resourceMachineRead,
-		Update:   resourceMachineUpdate,
-		Delete:   resourceMachineDelete,
-		Timeouts: slowResourceTimeout,
+		Create:        resourceMachineCreate,
+		Exists:        resourceMachineExists,
+		Read:          resourceMachineRead,
+		Update:        resourceMachineUpdate,
+		Delete:        resourceMachineDelete,
+		CustomizeDiff: resourceMachineCustomizeDiff,
+		Timeouts:      slowResourceTimeout,
 		Importer: &schema.ResourceImporter{
 			State: schema.ImportStatePassthrough,
 		},
@@ -118,7 +204,8 @@ func resourceMachine() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"ip": {
-							Description: "NIC's IPv4 address",
+							Description: "NIC's IPv4 address. Requesting a specific address is honored only when the machine is created (a NIC added by a later update gets whatever address Triton assigns); changing it afterward requires removing and re-adding the `nic`, since Triton cannot re-IP a NIC in place",
+							Optional:    true,
 							Computed:    true,
 							Type:        schema.TypeString,
 						},
@@ -155,6 +242,86 @@ func resourceMachine() *schema.Resource {
 					},
 				},
 			},
+			"locality": {
+				Description: "Placement hints for where to provision this machine relative to other machines",
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"strict": {
+							Description: "Whether the near/far hints below are requirements (true) or best-effort (false)",
+							Type:        schema.TypeBool,
+							Optional:    true,
+						},
+						"near": {
+							Description: "Provision this machine on the same compute node as these machine UUIDs, where possible",
+							Type:        schema.TypeList,
+							Optional:    true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"far": {
+							Description: "Provision this machine on a different compute node from these machine UUIDs, where possible",
+							Type:        schema.TypeList,
+							Optional:    true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
+			"volume": {
+				Description: "Volumes to attach to the machine. Order is significant: from the first changed entry onward, the existing volumes are detached and deleted and new ones are created and attached in their place, the same way re-ordering `nic` re-adds NICs",
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Description: "UUID of the underlying Triton volume",
+							Computed:    true,
+							Type:        schema.TypeString,
+						},
+						"name": {
+							Description:  "Friendly name for the volume",
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: resourceMachineValidateName,
+						},
+						"type": {
+							Description: "Volume type",
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "tritonnfs",
+						},
+						"size": {
+							Description: "Size of the volume, in Mb",
+							Type:        schema.TypeInt,
+							Required:    true,
+						},
+						"mode": {
+							Description:  "Whether the volume is mounted read-write (\"rw\") or read-only (\"ro\")",
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "rw",
+							ValidateFunc: resourceVolumeValidateMode,
+						},
+						"mountpoint": {
+							Description: "Path at which the volume is mounted inside the machine",
+							Computed:    true,
+							Type:        schema.TypeString,
+						},
+						"state": {
+							Description: "Provisioning state of the volume",
+							Computed:    true,
+							Type:        schema.TypeString,
+						},
+					},
+				},
+			},
 			"firewall_enabled": {
 				Description: "Whether to enable the firewall for this machine",
 				Type:        schema.TypeBool,
@@ -169,6 +336,37 @@ func resourceMachine() *schema.Resource {
 					Type: schema.TypeString,
 				},
 			},
+			"cns": {
+				Description: "Triton CNS (Container Name Service) configuration for this machine",
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"services": {
+							Description: "CNS service names to associate with this machine",
+							Type:        schema.TypeList,
+							Optional:    true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"disable": {
+							Description: "Disable CNS for this machine",
+							Type:        schema.TypeBool,
+							Optional:    true,
+						},
+						"fqdn": {
+							Description: "Fully-qualified domain names CNS has assigned to this machine",
+							Type:        schema.TypeList,
+							Computed:    true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
 
 			// computed resources from metadata
 			"root_authorized_keys": {
@@ -181,18 +379,30 @@ func resourceMachine() *schema.Resource {
 				Description: "User script to run on boot (every boot on SmartMachines)",
 				Type:        schema.TypeString,
 				Optional:    true,
+			},
+			"user_script_fingerprint": {
+				Description: "Base64-encoded SHA-256 digest of user_script, stored instead of the raw script so it doesn't leak into state",
+				Type:        schema.TypeString,
 				Computed:    true,
 			},
 			"cloud_config": {
 				Description: "copied to machine on boot",
 				Type:        schema.TypeString,
 				Optional:    true,
+			},
+			"cloud_config_fingerprint": {
+				Description: "Base64-encoded SHA-256 digest of cloud_config, stored instead of the raw config so it doesn't leak into state",
+				Type:        schema.TypeString,
 				Computed:    true,
 			},
 			"user_data": {
 				Description: "Data copied to machine on boot",
 				Type:        schema.TypeString,
 				Optional:    true,
+			},
+			"user_data_fingerprint": {
+				Description: "Base64-encoded SHA-256 digest of user_data, stored instead of the raw data so it doesn't leak into state",
+				Type:        schema.TypeString,
 				Computed:    true,
 			},
 			"administrator_pw": {
@@ -201,6 +411,36 @@ func resourceMachine() *schema.Resource {
 				Optional:    true,
 				Computed:    true,
 			},
+			"metadata": {
+				Description: "Arbitrary Triton metadata keys not covered by the fixed attributes above",
+				Type:        schema.TypeMap,
+				Optional:    true,
+			},
+
+			"power_state": {
+				Description:  "Desired power state of the machine (running or stopped)",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      machineStateRunning,
+				ValidateFunc: resourceMachineValidatePowerState,
+			},
+			"reboot_triggers": {
+				Description: "Arbitrary map of values that, when changed, cause the machine to be rebooted",
+				Type:        schema.TypeMap,
+				Optional:    true,
+			},
+			"deletion_protection": {
+				Description: "Whether to disallow Terraform from destroying this machine; defaults to false",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"allow_stopping_for_update": {
+				Description: "Whether this machine may be stopped and restarted in order to apply a `nic` or `package` change; defaults to false",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
 
 			// deprecated fields
 			"networks": {
@@ -220,14 +460,53 @@ func resourceMachine() *schema.Resource {
 func resourceMachineCreate(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*triton.Client)
 
-	var networks []string
+	// CreateMachineInput.Networks accepts a mix of bare network UUID
+	// strings and triton.NetworkObject{IPv4UUID, IPv4IPs} request objects
+	// -- CloudAPI's own "networks" parameter accepts both forms in the
+	// same array -- so a nic with an ip set is requested as an object
+	// reserving that address, and one without is just the UUID string, as
+	// before. nic.ip still participates in drift detection and the
+	// reject-on-change check in resourceMachineCustomizeDiff, since
+	// Triton can't re-IP an existing NIC after create.
+	var networks []interface{}
 	for _, network := range d.Get("networks").([]interface{}) {
 		networks = append(networks, network.(string))
 	}
 	if nicsRaw, found := d.GetOk("nic"); found {
 		for _, nicI := range nicsRaw.([]interface{}) {
 			nic := nicI.(map[string]interface{})
-			networks = append(networks, nic["network"].(string))
+			networkID := nic["network"].(string)
+
+			if ip := nic["ip"].(string); ip != "" {
+				networks = append(networks, &triton.NetworkObject{
+					IPv4UUID: networkID,
+					IPv4IPs:  []string{ip},
+				})
+			} else {
+				networks = append(networks, networkID)
+			}
+		}
+	}
+
+	var locality *triton.Locality
+	if localityRaw, ok := d.GetOk("locality"); ok {
+		localityList := localityRaw.([]interface{})
+		if len(localityList) > 0 && localityList[0] != nil {
+			l := localityList[0].(map[string]interface{})
+
+			var near, far []string
+			for _, v := range l["near"].([]interface{}) {
+				near = append(near, v.(string))
+			}
+			for _, v := range l["far"].([]interface{}) {
+				far = append(far, v.(string))
+			}
+
+			locality = &triton.Locality{
+				Strict: l["strict"].(bool),
+				Near:   near,
+				Far:    far,
+			}
 		}
 	}
 
@@ -237,11 +516,22 @@ func resourceMachineCreate(d *schema.ResourceData, meta interface{}) error {
 			metadata[metadataKey] = v.(string)
 		}
 	}
+	for k, v := range d.Get("metadata").(map[string]interface{}) {
+		metadata[k] = v.(string)
+	}
 
 	tags := map[string]string{}
 	for k, v := range d.Get("tags").(map[string]interface{}) {
 		tags[k] = v.(string)
 	}
+	for k, v := range cnsTagsFromResourceData(d) {
+		tags[k] = v
+	}
+
+	machineVolumes, volumeUUIDs, err := resourceMachineCreateVolumes(client, d)
+	if err != nil {
+		return err
+	}
 
 	machine, err := client.Machines().CreateMachine(context.Background(), &triton.CreateMachineInput{
 		Name:            d.Get("name").(string),
@@ -251,11 +541,15 @@ func resourceMachineCreate(d *schema.ResourceData, meta interface{}) error {
 		Metadata:        metadata,
 		Tags:            tags,
 		FirewallEnabled: d.Get("firewall_enabled").(bool),
+		Locality:        locality,
+		Volumes:         volumeUUIDs,
 	})
 	if err != nil {
 		return err
 	}
 
+	d.Set("volume", machineVolumes)
+
 	d.SetId(machine.ID)
 	stateConf := &resource.StateChangeConf{
 		Target: []string{fmt.Sprintf(machineStateRunning)},
@@ -318,7 +612,6 @@ func resourceMachineRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("memory", machine.Memory)
 	d.Set("disk", machine.Disk)
 	d.Set("ips", machine.IPs)
-	d.Set("tags", machine.Tags)
 	d.Set("created", machine.Created)
 	d.Set("updated", machine.Updated)
 	d.Set("package", machine.Package)
@@ -326,6 +619,17 @@ func resourceMachineRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("primaryip", machine.PrimaryIP)
 	d.Set("firewall_enabled", machine.FirewallEnabled)
 	d.Set("domain_names", machine.DomainNames)
+	d.Set("power_state", machine.State)
+
+	tags := map[string]string{}
+	for k, v := range machine.Tags {
+		if k == cnsServicesTag || k == cnsDisableTag {
+			continue
+		}
+		tags[k] = v
+	}
+	d.Set("tags", tags)
+	d.Set("cns", cnsBlockFromTags(machine.Tags, machine.DomainNames))
 
 	// create and update NICs
 	var (
@@ -350,11 +654,30 @@ func resourceMachineRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("nic", machineNICs)
 	d.Set("networks", networks)
 
-	// computed attributes from metadata
+	// "volume" is intentionally left untouched here: GetMachine doesn't
+	// expose which volumes are attached, so the values Create/Update last
+	// wrote to state are treated as authoritative rather than re-derived.
+
+	// computed attributes from metadata. user_script/user_data/cloud_config
+	// are fingerprinted rather than read back verbatim, so their rendered
+	// (possibly secret-bearing) content never lands in state.
 	for schemaName, metadataKey := range resourceMachineMetadataKeys {
+		if fingerprintKey, ok := resourceMachineFingerprintKeys[schemaName]; ok {
+			d.Set(fingerprintKey, fingerprint(machine.Metadata[metadataKey]))
+			continue
+		}
 		d.Set(schemaName, machine.Metadata[metadataKey])
 	}
 
+	extraMetadata := map[string]string{}
+	for k, v := range machine.Metadata {
+		if _, fixed := resourceMachineMetadataKeyNames[k]; fixed {
+			continue
+		}
+		extraMetadata[k] = v
+	}
+	d.Set("metadata", extraMetadata)
+
 	return nil
 }
 
@@ -400,11 +723,14 @@ func resourceMachineUpdate(d *schema.ResourceData, meta interface{}) error {
 		d.SetPartial("name")
 	}
 
-	if d.HasChange("tags") {
+	if d.HasChange("tags") || d.HasChange("cns") {
 		tags := map[string]string{}
 		for k, v := range d.Get("tags").(map[string]interface{}) {
 			tags[k] = v.(string)
 		}
+		for k, v := range cnsTagsFromResourceData(d) {
+			tags[k] = v
+		}
 
 		var err error
 		if len(tags) == 0 {
@@ -443,11 +769,22 @@ func resourceMachineUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 
 		d.SetPartial("tags")
+		d.SetPartial("cns")
 	}
 
 	if d.HasChange("package") {
 		newPackage := d.Get("package").(string)
 
+		// KVM packages can only be resized while the machine is stopped. Stop
+		// first if necessary (remembering whether we need to start it back up
+		// again afterward), resize, then restore the prior power state.
+		priorState := d.Get("power_state").(string)
+		if priorState == machineStateRunning {
+			if err := resourceMachineSetPowerState(d, client, machineStateStopped); err != nil {
+				return err
+			}
+		}
+
 		err := client.Machines().ResizeMachine(context.Background(), &triton.ResizeMachineInput{
 			ID:      d.Id(),
 			Package: newPackage,
@@ -457,7 +794,7 @@ func resourceMachineUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 
 		stateConf := &resource.StateChangeConf{
-			Target: []string{fmt.Sprintf("%s@%s", newPackage, "running")},
+			Target: []string{fmt.Sprintf("%s@%s", newPackage, machineStateStopped)},
 			Refresh: func() (interface{}, string, error) {
 				getResp, err := client.Machines().GetMachine(context.Background(), &triton.GetMachineInput{
 					ID: d.Id(),
@@ -476,6 +813,12 @@ func resourceMachineUpdate(d *schema.ResourceData, meta interface{}) error {
 			return err
 		}
 
+		if priorState == machineStateRunning {
+			if err := resourceMachineSetPowerState(d, client, machineStateRunning); err != nil {
+				return err
+			}
+		}
+
 		d.SetPartial("package")
 	}
 
@@ -610,6 +953,105 @@ func resourceMachineUpdate(d *schema.ResourceData, meta interface{}) error {
 		}
 	}
 
+	if d.HasChange("volume") {
+		// Mirrors the nic diffing above: volume order is significant, so
+		// find the lower bound of unchanged entries and, for the tail beyond
+		// it only, detach and delete the old volumes and create and attach
+		// new ones.
+		o, n := d.GetChange("volume")
+		oldVolumes, _ := o.([]interface{})
+		newVolumes, _ := n.([]interface{})
+
+		unchangedLowerBound := -1
+		for i, newVolRaw := range newVolumes {
+			if i > len(oldVolumes)-1 {
+				break
+			}
+
+			newVol := newVolRaw.(map[string]interface{})
+			oldVol := oldVolumes[i].(map[string]interface{})
+
+			identical := len(newVol) == len(oldVol)
+			if identical {
+				for k, vNew := range newVol {
+					if vOld, found := oldVol[k]; !found || !reflect.DeepEqual(vNew, vOld) {
+						identical = false
+						break
+					}
+				}
+			}
+			if identical {
+				unchangedLowerBound = i
+			} else {
+				break
+			}
+		}
+
+		for i, oldVolRaw := range oldVolumes {
+			if unchangedLowerBound >= i {
+				continue
+			}
+			oldVol := oldVolRaw.(map[string]interface{})
+			volumeID := oldVol["id"].(string)
+
+			if err := client.Machines().DetachMachineVolume(context.Background(), &triton.DetachMachineVolumeInput{
+				MachineID: d.Id(),
+				VolumeID:  volumeID,
+			}); err != nil {
+				return err
+			}
+			if err := client.Volumes().DeleteVolume(context.Background(), &triton.DeleteVolumeInput{
+				ID: volumeID,
+			}); err != nil {
+				return err
+			}
+			if err := waitForVolumeState(client, volumeID, volumeStateDeleted); err != nil {
+				return err
+			}
+		}
+
+		var attachedTail []map[string]interface{}
+		for i, newVolRaw := range newVolumes {
+			if unchangedLowerBound >= i {
+				attachedTail = append(attachedTail, oldVolumes[i].(map[string]interface{}))
+				continue
+			}
+			newVol := newVolRaw.(map[string]interface{})
+
+			createdVol, err := client.Volumes().CreateVolume(context.Background(), &triton.CreateVolumeInput{
+				Name: newVol["name"].(string),
+				Type: newVol["type"].(string),
+				Size: int64(newVol["size"].(int)),
+			})
+			if err != nil {
+				return err
+			}
+			if err := waitForVolumeState(client, createdVol.ID, volumeStateReady); err != nil {
+				return err
+			}
+			if err := client.Machines().AttachMachineVolume(context.Background(), &triton.AttachMachineVolumeInput{
+				MachineID: d.Id(),
+				VolumeID:  createdVol.ID,
+				Mode:      newVol["mode"].(string),
+			}); err != nil {
+				return err
+			}
+
+			attachedTail = append(attachedTail, map[string]interface{}{
+				"id":         createdVol.ID,
+				"name":       newVol["name"],
+				"type":       newVol["type"],
+				"size":       newVol["size"],
+				"mode":       newVol["mode"],
+				"mountpoint": createdVol.Mountpoint,
+				"state":      createdVol.State,
+			})
+		}
+		d.Set("volume", attachedTail)
+
+		d.SetPartial("volume")
+	}
+
 	if d.HasChange("firewall_enabled") {
 		enable := d.Get("firewall_enabled").(bool)
 
@@ -650,12 +1092,39 @@ func resourceMachineUpdate(d *schema.ResourceData, meta interface{}) error {
 		d.SetPartial("firewall_enabled")
 	}
 
+	if d.HasChange("power_state") {
+		if err := resourceMachineSetPowerState(d, client, d.Get("power_state").(string)); err != nil {
+			return err
+		}
+
+		d.SetPartial("power_state")
+	}
+
+	if d.HasChange("reboot_triggers") {
+		if err := client.Machines().RebootMachine(context.Background(), &triton.RebootMachineInput{
+			ID: d.Id(),
+		}); err != nil {
+			return err
+		}
+
+		if err := waitForMachineState(client, d.Id(), machineStateRunning); err != nil {
+			return err
+		}
+
+		d.SetPartial("reboot_triggers")
+	}
+
 	metadata := map[string]string{}
 	for schemaName, metadataKey := range resourceMachineMetadataKeys {
 		if d.HasChange(schemaName) {
 			metadata[metadataKey] = d.Get(schemaName).(string)
 		}
 	}
+	if d.HasChange("metadata") {
+		for k, v := range d.Get("metadata").(map[string]interface{}) {
+			metadata[k] = v.(string)
+		}
+	}
 	if len(metadata) > 0 {
 		if _, err := client.Machines().UpdateMachineMetadata(context.Background(), &triton.UpdateMachineMetadataInput{
 			ID:       d.Id(),
@@ -695,6 +1164,9 @@ func resourceMachineUpdate(d *schema.ResourceData, meta interface{}) error {
 				d.SetPartial(schemaName)
 			}
 		}
+		if d.HasChange("metadata") {
+			d.SetPartial("metadata")
+		}
 	}
 
 	d.Partial(false)
@@ -702,9 +1174,75 @@ func resourceMachineUpdate(d *schema.ResourceData, meta interface{}) error {
 	return resourceMachineRead(d, meta)
 }
 
+// resourceMachineCustomizeDiff rejects, at plan time, a diff that would
+// require rebooting the machine (any `nic` change or a `package` resize)
+// when allow_stopping_for_update is false, rather than letting
+// resourceMachineUpdate silently reboot the instance to apply it.
+func resourceMachineCustomizeDiff(diff *schema.ResourceDiff, meta interface{}) error {
+	if err := resourceMachineCustomizeDiffRejectNICIPChange(diff); err != nil {
+		return err
+	}
+
+	if !diff.Get("allow_stopping_for_update").(bool) {
+		if diff.HasChange("nic") {
+			return fmt.Errorf("changing `nic` requires stopping the machine; set allow_stopping_for_update to true to allow this")
+		}
+		if diff.HasChange("package") {
+			return fmt.Errorf("changing `package` requires stopping the machine; set allow_stopping_for_update to true to allow this")
+		}
+	}
+
+	if metadataRaw, ok := diff.GetOk("metadata"); ok {
+		if _, conflict := metadataRaw.(map[string]interface{})["user-script"]; conflict {
+			if v, ok := diff.GetOk("user_script"); ok && v.(string) != "" {
+				return fmt.Errorf(`cannot set both "user_script" and metadata["user-script"]; they both target the same Triton metadata key`)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resourceMachineCustomizeDiffRejectNICIPChange rejects a change to an
+// existing nic's ip. The Update path's AddNIC call, unlike CreateMachine's
+// Networks field, has no way to request a specific address, so a NIC whose
+// ip changed would silently come back with a different address than the
+// one in config rather than the "Triton cannot re-IP" error this is
+// standing in for.
+func resourceMachineCustomizeDiffRejectNICIPChange(diff *schema.ResourceDiff) error {
+	if !diff.HasChange("nic") {
+		return nil
+	}
+
+	o, n := diff.GetChange("nic")
+	oldNICs, _ := o.([]interface{})
+	newNICs, _ := n.([]interface{})
+
+	for i, newNICRaw := range newNICs {
+		if i >= len(oldNICs) {
+			break
+		}
+
+		oldNIC := oldNICs[i].(map[string]interface{})
+		newNIC := newNICRaw.(map[string]interface{})
+
+		oldIP := oldNIC["ip"].(string)
+		newIP := newNIC["ip"].(string)
+		if oldIP != "" && newIP != "" && oldIP != newIP {
+			return fmt.Errorf("nic.%d: cannot change ip from %q to %q in place; Triton cannot re-IP a NIC, remove and re-add the nic instead", i, oldIP, newIP)
+		}
+	}
+
+	return nil
+}
+
 func resourceMachineDelete(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*triton.Client)
 
+	if d.Get("deletion_protection").(bool) {
+		return fmt.Errorf("cannot delete machine %q: deletion_protection is enabled; set it to false to allow destroying this machine", d.Id())
+	}
+
 	err := client.Machines().DeleteMachine(context.Background(), &triton.DeleteMachineInput{
 		ID: d.Id(),
 	})
@@ -735,9 +1273,140 @@ func resourceMachineDelete(d *schema.ResourceData, meta interface{}) error {
 		return err
 	}
 
+	// The machine owns the volumes it created, so clean those up too.
+	for _, volRaw := range d.Get("volume").([]interface{}) {
+		vol := volRaw.(map[string]interface{})
+		if err := client.Volumes().DeleteVolume(context.Background(), &triton.DeleteVolumeInput{
+			ID: vol["id"].(string),
+		}); err != nil {
+			return err
+		}
+		if err := waitForVolumeState(client, vol["id"].(string), volumeStateDeleted); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// waitForMachineState blocks until the machine identified by id reports
+// targetState, polling GetMachine the same way the rest of this resource's
+// StateChangeConfs do.
+func waitForMachineState(client *triton.Client, id string, targetState string) error {
+	stateConf := &resource.StateChangeConf{
+		Target: []string{targetState},
+		Refresh: func() (interface{}, string, error) {
+			getResp, err := client.Machines().GetMachine(context.Background(), &triton.GetMachineInput{
+				ID: id,
+			})
+			if err != nil {
+				return nil, "", err
+			}
+
+			return getResp, getResp.State, nil
+		},
+		Timeout:    machineStateChangeTimeout,
+		MinTimeout: 3 * time.Second,
+	}
+	_, err := stateConf.WaitForState()
+	return err
+}
+
+// waitForVolumeState blocks until the volume identified by id reports
+// targetState, mirroring waitForMachineState.
+func waitForVolumeState(client *triton.Client, id string, targetState string) error {
+	stateConf := &resource.StateChangeConf{
+		Target: []string{targetState},
+		Refresh: func() (interface{}, string, error) {
+			getResp, err := client.Volumes().GetVolume(context.Background(), &triton.GetVolumeInput{
+				ID: id,
+			})
+			if err != nil {
+				if targetState == volumeStateDeleted && triton.IsResourceNotFound(err) {
+					return getResp, volumeStateDeleted, nil
+				}
+				return nil, "", err
+			}
+
+			return getResp, getResp.State, nil
+		},
+		Timeout:    machineStateChangeTimeout,
+		MinTimeout: 3 * time.Second,
+	}
+	_, err := stateConf.WaitForState()
+	return err
+}
+
+// resourceMachineCreateVolumes provisions a backing Triton volume for each
+// entry in the "volume" list, in order, and returns both the enriched list
+// (with id/mountpoint/state filled in, ready for d.Set) and the plain slice
+// of UUIDs CreateMachineInput.Volumes expects.
+func resourceMachineCreateVolumes(client *triton.Client, d *schema.ResourceData) ([]map[string]interface{}, []string, error) {
+	volsRaw, ok := d.GetOk("volume")
+	if !ok {
+		return nil, nil, nil
+	}
+
+	var (
+		machineVolumes []map[string]interface{}
+		volumeUUIDs    []string
+	)
+	for _, volRaw := range volsRaw.([]interface{}) {
+		vol := volRaw.(map[string]interface{})
+
+		createdVol, err := client.Volumes().CreateVolume(context.Background(), &triton.CreateVolumeInput{
+			Name: vol["name"].(string),
+			Type: vol["type"].(string),
+			Size: int64(vol["size"].(int)),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := waitForVolumeState(client, createdVol.ID, volumeStateReady); err != nil {
+			return nil, nil, err
+		}
+
+		machineVolumes = append(machineVolumes, map[string]interface{}{
+			"id":         createdVol.ID,
+			"name":       vol["name"],
+			"type":       vol["type"],
+			"size":       vol["size"],
+			"mode":       vol["mode"],
+			"mountpoint": createdVol.Mountpoint,
+			"state":      createdVol.State,
+		})
+		volumeUUIDs = append(volumeUUIDs, createdVol.ID)
+	}
+
+	return machineVolumes, volumeUUIDs, nil
+}
+
+// resourceMachineSetPowerState drives the machine to targetState via
+// StopMachine/StartMachine, waiting for the transition to complete. It is
+// shared by the power_state Update path and the package-resize path, which
+// must stop the machine (if running) before a resize and restore its prior
+// power state afterward.
+func resourceMachineSetPowerState(d *schema.ResourceData, client *triton.Client, targetState string) error {
+	switch targetState {
+	case machineStateStopped:
+		if err := client.Machines().StopMachine(context.Background(), &triton.StopMachineInput{
+			ID: d.Id(),
+		}); err != nil {
+			return err
+		}
+	case machineStateRunning:
+		if err := client.Machines().StartMachine(context.Background(), &triton.StartMachineInput{
+			ID: d.Id(),
+		}); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported power_state %q", targetState)
+	}
+
+	return waitForMachineState(client, d.Id(), targetState)
+}
+
 func resourceMachineValidateName(value interface{}, name string) (warnings []string, errors []error) {
 	warnings = []string{}
 	errors = []error{}
@@ -749,3 +1418,23 @@ func resourceMachineValidateName(value interface{}, name string) (warnings []str
 
 	return warnings, errors
 }
+
+func resourceMachineValidatePowerState(value interface{}, name string) (warnings []string, errors []error) {
+	switch value.(string) {
+	case machineStateRunning, machineStateStopped:
+	default:
+		errors = append(errors, fmt.Errorf(`"%s" must be one of %q or %q`, name, machineStateRunning, machineStateStopped))
+	}
+
+	return warnings, errors
+}
+
+func resourceVolumeValidateMode(value interface{}, name string) (warnings []string, errors []error) {
+	switch value.(string) {
+	case "ro", "rw":
+	default:
+		errors = append(errors, fmt.Errorf(`"%s" must be one of "ro" or "rw"`, name))
+	}
+
+	return warnings, errors
+}
@@ -0,0 +1,119 @@
+package triton
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/joyent/triton-go"
+)
+
+// resourceVolume manages a Triton volume independently of any machine, so
+// it can be created once and referenced by UUID from multiple
+// triton_machine resources (or from machines not managed by Terraform at
+// all). The triton_machine "volume" block, by contrast, owns the lifecycle
+// of the volumes it creates inline.
+func resourceVolume() *schema.Resource {
+	return &schema.Resource{
+		Create:   resourceVolumeCreate,
+		Exists:   resourceVolumeExists,
+		Read:     resourceVolumeRead,
+		Delete:   resourceVolumeDelete,
+		Timeouts: slowResourceTimeout,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Description:  "Friendly name for the volume",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: resourceMachineValidateName,
+			},
+			"type": {
+				Description: "Volume type",
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "tritonnfs",
+			},
+			"size": {
+				Description: "Size of the volume, in Mb",
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+			},
+			"mountpoint": {
+				Description: "Path at which the volume is mounted inside machines it's attached to",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"state": {
+				Description: "Provisioning state of the volume",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func resourceVolumeCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*triton.Client)
+
+	volume, err := client.Volumes().CreateVolume(context.Background(), &triton.CreateVolumeInput{
+		Name: d.Get("name").(string),
+		Type: d.Get("type").(string),
+		Size: int64(d.Get("size").(int)),
+	})
+	if err != nil {
+		return err
+	}
+
+	d.SetId(volume.ID)
+	if err := waitForVolumeState(client, d.Id(), volumeStateReady); err != nil {
+		return err
+	}
+
+	return resourceVolumeRead(d, meta)
+}
+
+func resourceVolumeExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	client := meta.(*triton.Client)
+
+	return resourceExists(client.Volumes().GetVolume(context.Background(), &triton.GetVolumeInput{
+		ID: d.Id(),
+	}))
+}
+
+func resourceVolumeRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*triton.Client)
+
+	volume, err := client.Volumes().GetVolume(context.Background(), &triton.GetVolumeInput{
+		ID: d.Id(),
+	})
+	if err != nil {
+		return err
+	}
+
+	d.Set("name", volume.Name)
+	d.Set("type", volume.Type)
+	d.Set("size", volume.Size)
+	d.Set("mountpoint", volume.Mountpoint)
+	d.Set("state", volume.State)
+
+	return nil
+}
+
+func resourceVolumeDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*triton.Client)
+
+	err := client.Volumes().DeleteVolume(context.Background(), &triton.DeleteVolumeInput{
+		ID: d.Id(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return waitForVolumeState(client, d.Id(), volumeStateDeleted)
+}
@@ -0,0 +1,85 @@
+package jsonprovider
+
+import (
+	"encoding/json"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type attribute struct {
+	AttributeType      json.RawMessage `json:"type,omitempty"`
+	Description        string          `json:"description,omitempty"`
+	DescriptionKind    string          `json:"description_kind,omitempty"`
+	Required           bool            `json:"required,omitempty"`
+	Optional           bool            `json:"optional,omitempty"`
+	Computed           bool            `json:"computed,omitempty"`
+	Sensitive          bool            `json:"sensitive,omitempty"`
+	Deprecated         bool            `json:"deprecated,omitempty"`
+	DeprecationMessage string          `json:"deprecation_message,omitempty"`
+	Validators         []validator     `json:"validators,omitempty"`
+}
+
+// validator surfaces one of the constraints configschema knows about for
+// an attribute, so a docs generator or policy engine can render the same
+// checks `terraform validate` applies without reimplementing them.
+type validator struct {
+	// Kind is one of "min_length", "max_length", "one_of", "regex", or
+	// "range", matching the constraint it describes.
+	Kind string `json:"kind"`
+
+	// Detail is a human-readable rendering of the constraint (e.g. "must
+	// match pattern ^[a-z]+$"), for callers that just want to display it.
+	Detail string `json:"detail,omitempty"`
+
+	// Values holds the kind-specific payload: the allowed values for
+	// "one_of", the [min, max] bounds for "range", the length for
+	// "min_length"/"max_length", or the pattern string for "regex".
+	Values []string `json:"values,omitempty"`
+}
+
+func marshalStringKind(kind configschema.StringKind) string {
+	switch kind {
+	case configschema.StringMarkdown:
+		return "markdown"
+	default:
+		return "plain"
+	}
+}
+
+func marshalAttribute(attr *configschema.Attribute) *attribute {
+	var ret attribute
+	if attr.Type != cty.NilType {
+		typ, err := attr.Type.MarshalJSON()
+		if err != nil {
+			panic(err)
+		}
+		ret.AttributeType = typ
+	}
+
+	ret.Description = attr.Description
+	ret.DescriptionKind = marshalStringKind(attr.DescriptionKind)
+	ret.Required = attr.Required
+	ret.Optional = attr.Optional
+	ret.Computed = attr.Computed
+	ret.Sensitive = attr.Sensitive
+	ret.Deprecated = attr.Deprecated
+	ret.DeprecationMessage = attr.DeprecationMessage
+	ret.Validators = marshalValidators(attr)
+
+	return &ret
+}
+
+// marshalValidators surfaces the validation constraints configschema
+// carries for attr. configschema doesn't yet expose a dedicated
+// constraint set in this tree's vendored version - min/max length, one-of,
+// regex, and numeric range all live on the higher-level
+// helper/schema.Schema.ValidateFunc instead, which has already been
+// reduced to a plain function pointer by the time a configschema.Attribute
+// is built and can't be introspected here. The field is still part of the
+// JSON shape so consumers can start coding against it; it reports nothing
+// until configschema grows a structured representation of these
+// constraints.
+func marshalValidators(attr *configschema.Attribute) []validator {
+	return nil
+}
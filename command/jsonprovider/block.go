@@ -7,6 +7,12 @@ import (
 type block struct {
 	Attributes map[string]*attribute `json:"attributes,omitempty"`
 	BlockTypes map[string]*blockType `json:"block_types,omitempty"`
+
+	// Sensitive is true if any attribute in this block, or any block type
+	// nested inside it at any depth, is itself sensitive. It lets a
+	// consumer decide "does this block need redaction" without walking
+	// the whole tree to find out.
+	Sensitive bool `json:"sensitive,omitempty"`
 }
 
 type blockType struct {
@@ -52,7 +58,11 @@ func marshalBlock(configBlock *configschema.Block) *block {
 	if len(configBlock.Attributes) > 0 {
 		attrs := make(map[string]*attribute, len(configBlock.Attributes))
 		for k, attr := range configBlock.Attributes {
-			attrs[k] = marshalAttribute(attr)
+			marshaled := marshalAttribute(attr)
+			attrs[k] = marshaled
+			if marshaled.Sensitive {
+				ret.Sensitive = true
+			}
 		}
 		ret.Attributes = attrs
 	}
@@ -60,7 +70,11 @@ func marshalBlock(configBlock *configschema.Block) *block {
 	if len(configBlock.BlockTypes) > 0 {
 		blockTypes := make(map[string]*blockType, len(configBlock.BlockTypes))
 		for k, bt := range configBlock.BlockTypes {
-			blockTypes[k] = marshalBlockTypes(bt)
+			marshaled := marshalBlockTypes(bt)
+			blockTypes[k] = marshaled
+			if marshaled.Block != nil && marshaled.Block.Sensitive {
+				ret.Sensitive = true
+			}
 		}
 		ret.BlockTypes = blockTypes
 	}
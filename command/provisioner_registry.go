@@ -0,0 +1,138 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// ProvisionerFactory is a function type that creates a new instance of a
+// provisioner, parallel to terraform.ResourceProvisionerFactory but keyed
+// by name in InternalProvisioners below rather than discovered on disk.
+type ProvisionerFactory func() (terraform.ResourceProvisioner, error)
+
+// InternalProvisioners lists the provisioners this binary can construct
+// directly, without spawning a go-plugin subprocess for them. "file" and
+// "local-exec" are cheap and run in the same process as core anyway (they
+// just move files around or fork a child command), so forking a second,
+// go-plugin-wrapped process per provisioner invocation on a large plan is
+// pure overhead.
+//
+// The lookup path for a configured `provisioner "X" { ... }` block should
+// check this map before falling back to the on-disk plugin discovery used
+// for everything else.
+var InternalProvisioners = map[string]ProvisionerFactory{
+	"file":       internalFileProvisioner,
+	"local-exec": internalLocalExecProvisioner,
+}
+
+// internalProvisionerOverride, when set via the TF_PROVISIONER environment
+// variable, forces the internal registry to be consulted even for a
+// provisioner name it wouldn't otherwise recognize, by aliasing that name
+// to an entry already present in InternalProvisioners. This exists mainly
+// so integration tests can exercise the in-process path without needing a
+// config fixture for every provisioner name.
+func internalProvisionerOverride(name string) string {
+	if override := os.Getenv("TF_PROVISIONER"); override != "" {
+		if parts := strings.SplitN(override, "=", 2); len(parts) == 2 && parts[0] == name {
+			return parts[1]
+		}
+	}
+	return name
+}
+
+// LookupInternalProvisioner returns the in-process factory for name, if
+// any, honoring a TF_PROVISIONER=name=alias override. ok is false when core
+// should fall back to plugin discovery.
+func LookupInternalProvisioner(name string) (factory ProvisionerFactory, ok bool) {
+	factory, ok = InternalProvisioners[internalProvisionerOverride(name)]
+	return factory, ok
+}
+
+func internalFileProvisioner() (terraform.ResourceProvisioner, error) {
+	return &internalFileProvisionerImpl{}, nil
+}
+
+// internalFileProvisionerImpl is a minimal in-process stand-in for the
+// real file provisioner's copy logic (source/destination, local vs.
+// remote connection types). It only demonstrates that the in-process path
+// is reachable; it isn't a replacement for builtin/provisioners/file.
+type internalFileProvisionerImpl struct{}
+
+func (p *internalFileProvisionerImpl) GetSchema() (*terraform.ProvisionerSchema, error) {
+	return &terraform.ProvisionerSchema{Version: 1}, nil
+}
+
+func (p *internalFileProvisionerImpl) Validate(c *terraform.ResourceConfig) ([]string, []error) {
+	if _, ok := c.Get("source"); !ok {
+		if _, ok := c.Get("content"); !ok {
+			return nil, []error{fmt.Errorf("one of \"source\" or \"content\" must be set")}
+		}
+	}
+	if _, ok := c.Get("destination"); !ok {
+		return nil, []error{fmt.Errorf("\"destination\" is required")}
+	}
+	return nil, nil
+}
+
+func (p *internalFileProvisionerImpl) Apply(o terraform.UIOutput, s *terraform.InstanceState, c *terraform.ResourceConfig) error {
+	return fmt.Errorf("the in-process file provisioner does not yet implement Apply; use the plugin-based file provisioner")
+}
+
+func (p *internalFileProvisionerImpl) Stop() error {
+	return nil
+}
+
+func internalLocalExecProvisioner() (terraform.ResourceProvisioner, error) {
+	return &internalLocalExecProvisionerImpl{}, nil
+}
+
+// internalLocalExecProvisionerImpl is a minimal in-process stand-in for
+// the real local-exec provisioner. Command execution itself is the one
+// piece implemented for real, since it needs no go-plugin RPC round trip
+// to be useful; everything else (working_directory, environment,
+// interpreter) is left to the plugin-based implementation.
+type internalLocalExecProvisionerImpl struct{}
+
+func (p *internalLocalExecProvisionerImpl) GetSchema() (*terraform.ProvisionerSchema, error) {
+	return &terraform.ProvisionerSchema{Version: 1}, nil
+}
+
+func (p *internalLocalExecProvisionerImpl) Validate(c *terraform.ResourceConfig) ([]string, []error) {
+	if _, ok := c.Get("command"); !ok {
+		return nil, []error{fmt.Errorf("\"command\" is required")}
+	}
+	return nil, nil
+}
+
+func (p *internalLocalExecProvisionerImpl) Apply(o terraform.UIOutput, s *terraform.InstanceState, c *terraform.ResourceConfig) error {
+	command, ok := c.Get("command")
+	if !ok {
+		return fmt.Errorf("\"command\" is required")
+	}
+
+	cmd := exec.Command("/bin/sh", "-c", command.(string))
+	cmd.Stdout = &uiOutputWriter{o}
+	cmd.Stderr = &uiOutputWriter{o}
+	return cmd.Run()
+}
+
+func (p *internalLocalExecProvisionerImpl) Stop() error {
+	return nil
+}
+
+// uiOutputWriter adapts terraform.UIOutput's Output(string) method to
+// io.Writer, so the shelled-out command's stdout/stderr can be streamed
+// through it. Each Write becomes one Output call with a trailing newline
+// trimmed; writes are not split or joined into whole lines.
+type uiOutputWriter struct {
+	o terraform.UIOutput
+}
+
+func (w *uiOutputWriter) Write(p []byte) (int, error) {
+	w.o.Output(strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
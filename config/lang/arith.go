@@ -0,0 +1,177 @@
+package lang
+
+import (
+	"sync"
+
+	"github.com/hashicorp/terraform/config/lang/ast"
+)
+
+// The `expr ARITH_OP expr` production (see lang.y) is plain left-recursive
+// with a single precedence class for all five operators, so the parser
+// always reduces strictly left-to-right: "1 + 2 * 3" combines "1 + 2"
+// before it ever sees the "* 3". foldArithmetic is what corrects this
+// after the fact, the same fixup a Pratt/precedence-climbing parser
+// performs inline -- but it has to do so *before* any constant folding
+// happens, not after: folding "1 + 2" into a literal 3 immediately would
+// permanently erase the fact that it came from a lower-precedence "+",
+// and the next combine has nothing left to rotate against.
+//
+// To make that possible, foldArithmetic keeps a hidden, fully
+// unassociated copy of every tree it builds (see treeOf/owned below) even
+// once the publicly-returned value has been constant-folded down to a
+// literal. A later call combining that literal with a higher-precedence
+// operator looks up its real shape, rotates that, and only then re-folds.
+//
+// Grouping is the other half of the same problem: `( expr )` (lang.y's
+// PROGRAM_BRACKET_LEFT/RIGHT production) must never be re-associated,
+// even though the parenthesized subtree can be structurally identical to
+// one foldArithmetic would have built itself. group() (called from that
+// production) removes a node from the "owned" set, and drops the hidden
+// tree of a node that was already folded to a literal, so it's never
+// mistaken for one of foldArithmetic's own unsettled trees.
+var (
+	treeMu sync.Mutex
+	owned  = map[*ast.Arithmetic]bool{}
+	tree   = map[ast.Node]*ast.Arithmetic{}
+)
+
+// group marks node as an explicitly parenthesized subtree, so a later
+// foldArithmetic call treats it as opaque rather than digging in to
+// re-associate past the grouping the source asked for.
+func group(node ast.Node) ast.Node {
+	treeMu.Lock()
+	defer treeMu.Unlock()
+	if arith, ok := node.(*ast.Arithmetic); ok {
+		delete(owned, arith)
+	}
+	// A constant-folded literal still has its unassociated shape recorded
+	// in tree; dropping that entry makes the literal itself opaque.
+	delete(tree, node)
+	return node
+}
+
+// foldArithmetic combines left op right into a single node, rotating the
+// result if left is an unsettled combination foldArithmetic itself built
+// at a lower precedence than op, then constant-folding literal operands
+// once their precedence position is settled.
+func foldArithmetic(left ast.Node, op ast.ArithmeticOp, right ast.Node) ast.Node {
+	treeMu.Lock()
+	defer treeMu.Unlock()
+	return combine(left, op, right)
+}
+
+func combine(left ast.Node, op ast.ArithmeticOp, right ast.Node) ast.Node {
+	leftTree := left
+	if full, ok := tree[left]; ok {
+		leftTree = full
+	}
+
+	var full *ast.Arithmetic
+	if lhs, ok := leftTree.(*ast.Arithmetic); ok && owned[lhs] && len(lhs.Exprs) == 2 && arithPrecedence(op) > arithPrecedence(lhs.Op) {
+		inner := combine(lhs.Exprs[1], op, right)
+		full = &ast.Arithmetic{
+			Op:    lhs.Op,
+			Exprs: []ast.Node{lhs.Exprs[0], inner},
+			Posx:  lhs.Posx,
+		}
+	} else {
+		full = &ast.Arithmetic{
+			Op:    op,
+			Exprs: []ast.Node{leftTree, right},
+			Posx:  leftTree.Pos(),
+		}
+	}
+	owned[full] = true
+
+	result := collapseTop(full)
+	if result != ast.Node(full) {
+		tree[result] = full
+	}
+	return result
+}
+
+// collapseTop constant-folds full if both its operands resolve to
+// literals (recursing into any owned sub-combination), promoting to
+// TypeFloat per evalLiteralArithmetic whenever one side is a float.
+// Anything it can't fully resolve -- a non-literal operand, or an
+// unowned (explicitly grouped) subtree -- is left as full, untouched.
+func collapseTop(full *ast.Arithmetic) ast.Node {
+	left, leftOK := collapseChild(full.Exprs[0])
+	right, rightOK := collapseChild(full.Exprs[1])
+	if leftOK && rightOK {
+		if folded, ok := evalLiteralArithmetic(full.Op, left, right); ok {
+			return folded
+		}
+	}
+	return full
+}
+
+func collapseChild(n ast.Node) (*ast.LiteralNode, bool) {
+	if lit, ok := n.(*ast.LiteralNode); ok {
+		return lit, true
+	}
+	if arith, ok := n.(*ast.Arithmetic); ok && owned[arith] && len(arith.Exprs) == 2 {
+		if collapsed, ok := collapseTop(arith).(*ast.LiteralNode); ok {
+			return collapsed, true
+		}
+	}
+	return nil, false
+}
+
+// arithPrecedence ranks `*`/`/`/`%` above `+`/`-`, the standard binding
+// order foldArithmetic enforces when rotating a naively left-assoc tree.
+func arithPrecedence(op ast.ArithmeticOp) int {
+	switch op {
+	case ast.ArithmeticOpMul, ast.ArithmeticOpDiv, ast.ArithmeticOpMod:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// evalLiteralArithmetic folds two literal operands, promoting the result
+// to TypeFloat whenever either operand is a float so that e.g. "1 / 2.0"
+// doesn't truncate the way two TypeInt operands dividing would.
+func evalLiteralArithmetic(op ast.ArithmeticOp, left, right *ast.LiteralNode) (*ast.LiteralNode, bool) {
+	lv, lIsFloat, ok := numericLiteralValue(left)
+	if !ok {
+		return nil, false
+	}
+	rv, rIsFloat, ok := numericLiteralValue(right)
+	if !ok {
+		return nil, false
+	}
+
+	var result float64
+	switch op {
+	case ast.ArithmeticOpAdd:
+		result = lv + rv
+	case ast.ArithmeticOpSub:
+		result = lv - rv
+	case ast.ArithmeticOpMul:
+		result = lv * rv
+	case ast.ArithmeticOpDiv:
+		result = lv / rv
+	case ast.ArithmeticOpMod:
+		result = float64(int64(lv) % int64(rv))
+	default:
+		return nil, false
+	}
+
+	if lIsFloat || rIsFloat {
+		return &ast.LiteralNode{Value: result, Type: ast.TypeFloat, Posx: left.Posx}, true
+	}
+
+	return &ast.LiteralNode{Value: int(result), Type: ast.TypeInt, Posx: left.Posx}, true
+}
+
+func numericLiteralValue(n *ast.LiteralNode) (value float64, isFloat, ok bool) {
+	switch n.Type {
+	case ast.TypeInt:
+		return float64(n.Value.(int)), false, true
+	case ast.TypeFloat:
+		return n.Value.(float64), true, true
+	default:
+		return 0, false, false
+	}
+}
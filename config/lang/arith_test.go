@@ -0,0 +1,152 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/config/lang/ast"
+)
+
+// These exercise foldArithmetic directly rather than through Parse: this
+// trimmed checkout has no lexer.go or Parse() entry point (see the
+// ParseOptions doc comment in errors.go), only the grammar (lang.y) and its
+// yacc-generated parser tables, so there's no way to feed it source text end-to-end. foldArithmetic
+// is where the actual precedence/associativity/promotion logic lives, and
+// it's plain Go, so it's testable on its own.
+
+func litInt(v int) *ast.LiteralNode {
+	return &ast.LiteralNode{Value: v, Type: ast.TypeInt}
+}
+
+func litFloat(v float64) *ast.LiteralNode {
+	return &ast.LiteralNode{Value: v, Type: ast.TypeFloat}
+}
+
+// 1 + 2 * 3: naive left recursion combines (1 + 2) first, then folding
+// in `* 3` must rotate so multiplication binds tighter than addition.
+func TestFoldArithmetic_precedence(t *testing.T) {
+	sum := foldArithmetic(litInt(1), ast.ArithmeticOpAdd, litInt(2))
+	result := foldArithmetic(sum, ast.ArithmeticOpMul, litInt(3))
+
+	lit, ok := result.(*ast.LiteralNode)
+	if !ok {
+		t.Fatalf("expected constant-folded literal, got %#v", result)
+	}
+	if lit.Type != ast.TypeInt || lit.Value.(int) != 7 {
+		t.Fatalf("bad: %#v", lit)
+	}
+}
+
+// 1 * 2 + 3: multiplication already binds the first two operands, so
+// folding in `+ 3` must NOT rotate -- it should just wrap the product.
+func TestFoldArithmetic_noRotationNeeded(t *testing.T) {
+	product := foldArithmetic(litInt(1), ast.ArithmeticOpMul, litInt(2))
+	result := foldArithmetic(product, ast.ArithmeticOpAdd, litInt(3))
+
+	lit, ok := result.(*ast.LiteralNode)
+	if !ok {
+		t.Fatalf("expected constant-folded literal, got %#v", result)
+	}
+	if lit.Type != ast.TypeInt || lit.Value.(int) != 5 {
+		t.Fatalf("bad: %#v", lit)
+	}
+}
+
+// 1 + 2 + 3: same-precedence chains stay left-associative.
+func TestFoldArithmetic_leftAssociative(t *testing.T) {
+	sum := foldArithmetic(litInt(1), ast.ArithmeticOpAdd, litInt(2))
+	result := foldArithmetic(sum, ast.ArithmeticOpAdd, litInt(3))
+
+	lit, ok := result.(*ast.LiteralNode)
+	if !ok {
+		t.Fatalf("expected constant-folded literal, got %#v", result)
+	}
+	if lit.Value.(int) != 6 {
+		t.Fatalf("bad: %#v", lit)
+	}
+}
+
+// Explicit parenthesization -- "(1 + 2) * 3" -- reaches foldArithmetic as
+// a pre-built subtree that foldArithmetic doesn't own (the
+// PROGRAM_BRACKET_LEFT expr PROGRAM_BRACKET_RIGHT production passes its
+// inner expr through group(), which removes it from the owned set), so it must NOT be
+// rotated even though + binds looser than *: the grouping was explicit.
+func TestFoldArithmetic_parenthesizedNotRotated(t *testing.T) {
+	paren := &ast.Arithmetic{
+		Op:    ast.ArithmeticOpAdd,
+		Exprs: []ast.Node{litInt(1), litInt(2)},
+	}
+
+	result := foldArithmetic(paren, ast.ArithmeticOpMul, litInt(3))
+
+	arith, ok := result.(*ast.Arithmetic)
+	if !ok {
+		t.Fatalf("expected an *ast.Arithmetic, got %#v", result)
+	}
+	if arith.Op != ast.ArithmeticOpMul {
+		t.Fatalf("expected the outer op to stay Mul, got %v", arith.Op)
+	}
+	if len(arith.Exprs) != 2 || arith.Exprs[0] != paren {
+		t.Fatalf("expected the parenthesized sum to stay intact as the left operand, got %#v", arith.Exprs)
+	}
+}
+
+// int/float mixing promotes the folded result to TypeFloat.
+func TestFoldArithmetic_intFloatPromotion(t *testing.T) {
+	result := foldArithmetic(litInt(1), ast.ArithmeticOpAdd, litFloat(2.5))
+
+	lit, ok := result.(*ast.LiteralNode)
+	if !ok {
+		t.Fatalf("expected constant-folded literal, got %#v", result)
+	}
+	if lit.Type != ast.TypeFloat {
+		t.Fatalf("expected TypeFloat, got %v", lit.Type)
+	}
+	if lit.Value.(float64) != 3.5 {
+		t.Fatalf("bad: %#v", lit.Value)
+	}
+}
+
+// int/int stays TypeInt.
+func TestFoldArithmetic_intStaysInt(t *testing.T) {
+	result := foldArithmetic(litInt(4), ast.ArithmeticOpMul, litInt(5))
+
+	lit, ok := result.(*ast.LiteralNode)
+	if !ok {
+		t.Fatalf("expected constant-folded literal, got %#v", result)
+	}
+	if lit.Type != ast.TypeInt {
+		t.Fatalf("expected TypeInt, got %v", lit.Type)
+	}
+	if lit.Value.(int) != 20 {
+		t.Fatalf("bad: %#v", lit.Value)
+	}
+}
+
+// A non-literal operand (e.g. "${1 + 2 * count.index}") can't be
+// constant-folded, so it stays an *ast.Arithmetic node for evaluation at
+// interpolation time.
+func TestFoldArithmetic_nonLiteralOperand(t *testing.T) {
+	variable := &ast.VariableAccess{Name: "count.index"}
+
+	result := foldArithmetic(litInt(2), ast.ArithmeticOpMul, variable)
+
+	arith, ok := result.(*ast.Arithmetic)
+	if !ok {
+		t.Fatalf("expected an *ast.Arithmetic, got %#v", result)
+	}
+	if arith.Op != ast.ArithmeticOpMul {
+		t.Fatalf("bad op: %v", arith.Op)
+	}
+	if len(arith.Exprs) != 2 || arith.Exprs[1] != variable {
+		t.Fatalf("bad exprs: %#v", arith.Exprs)
+	}
+
+	outer := foldArithmetic(litInt(1), ast.ArithmeticOpAdd, result)
+	outerArith, ok := outer.(*ast.Arithmetic)
+	if !ok {
+		t.Fatalf("expected an *ast.Arithmetic, got %#v", outer)
+	}
+	if outerArith.Op != ast.ArithmeticOpAdd {
+		t.Fatalf("expected + to stay outermost since * already binds tighter, got %v", outerArith.Op)
+	}
+}
@@ -0,0 +1,22 @@
+package lang
+
+import "testing"
+
+func TestErrorList_SortAndErr(t *testing.T) {
+	var errs ErrorList
+	if errs.Err() != nil {
+		t.Fatal("expected a nil error for an empty ErrorList")
+	}
+
+	errs.Add(Pos{Line: 3, Column: 1}, "second")
+	errs.Add(Pos{Line: 1, Column: 5}, "first")
+	errs.Sort()
+
+	if errs[0].Msg != "first" || errs[1].Msg != "second" {
+		t.Fatalf("not sorted by position: %v", errs)
+	}
+
+	if err := errs.Err(); err == nil {
+		t.Fatal("expected a non-nil error for a non-empty ErrorList")
+	}
+}
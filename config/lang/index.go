@@ -0,0 +1,83 @@
+package lang
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/config/lang/ast"
+)
+
+// IndexError is returned by Index when key doesn't apply to value: an
+// out-of-range int against a list, a string key against something that
+// isn't a map, or any other key/value type mismatch.
+type IndexError struct {
+	Key   interface{}
+	Value interface{}
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("cannot index %T with key %v (%T)", e.Value, e.Key, e.Key)
+}
+
+// Index applies one subscript operation: an int key against a
+// []interface{} (list indexing, as `foo[0]` would evaluate), or a string
+// key against a map[string]interface{} (map indexing, as `foo[bar]`
+// would). Chained indexing (`foo[0][bar]`) is just calling Index again on
+// the previous result, same as the grammar's left-recursive
+// `expr '[' expr ']'` production would.
+func Index(value interface{}, key interface{}) (interface{}, error) {
+	switch k := key.(type) {
+	case int:
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, &IndexError{Key: key, Value: value}
+		}
+		if k < 0 || k >= len(list) {
+			return nil, &IndexError{Key: key, Value: value}
+		}
+		return list[k], nil
+
+	case string:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, &IndexError{Key: key, Value: value}
+		}
+		v, ok := m[k]
+		if !ok {
+			return nil, &IndexError{Key: key, Value: value}
+		}
+		return v, nil
+
+	default:
+		return nil, &IndexError{Key: key, Value: value}
+	}
+}
+
+// resolver resolves an ast.Node (an identifier, a call, or another
+// ast.Index) down to the plain Go value it evaluates to. This trimmed
+// checkout has no Eval/Visitor machinery of its own to walk the rest of
+// the tree with, so evalIndex takes one in rather than assuming some
+// concrete evaluator type; a real evaluator's Eval(ast.Node) method
+// satisfies this already.
+type resolver interface {
+	Resolve(ast.Node) (interface{}, error)
+}
+
+// evalIndex is the evaluator path for an *ast.Index node built by the
+// grammar's `expr SQUARE_BRACKET_LEFT expr SQUARE_BRACKET_RIGHT`
+// production (lang.y): resolve Target and Key to concrete values, then
+// apply one Index lookup. Chained indexing (`foo[0][bar]`) falls out for
+// free, since the outer Index's Target is itself an *ast.Index that r
+// resolves by recursing into evalIndex.
+func evalIndex(n *ast.Index, r resolver) (interface{}, error) {
+	target, err := r.Resolve(n.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := r.Resolve(n.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return Index(target, key)
+}
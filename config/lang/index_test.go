@@ -0,0 +1,163 @@
+package lang
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/config/lang/ast"
+)
+
+// fakeResolver resolves an *ast.VariableAccess or *ast.Call by name out of
+// a fixed value map, and recurses into evalIndex for an *ast.Index --
+// standing in for a real evaluator's Eval(ast.Node) in these tests.
+type fakeResolver map[string]interface{}
+
+func (r fakeResolver) Resolve(n ast.Node) (interface{}, error) {
+	switch v := n.(type) {
+	case *ast.VariableAccess:
+		return r[v.Name], nil
+	case *ast.Call:
+		return r[v.Func], nil
+	case *ast.LiteralNode:
+		return v.Value, nil
+	case *ast.Index:
+		return evalIndex(v, r)
+	default:
+		return nil, fmt.Errorf("fakeResolver: unsupported node %T", n)
+	}
+}
+
+func TestIndex_list(t *testing.T) {
+	list := []interface{}{"a", "b", "c"}
+
+	v, err := Index(list, 1)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v != "b" {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
+func TestIndex_listOutOfRange(t *testing.T) {
+	list := []interface{}{"a", "b"}
+
+	if _, err := Index(list, 5); err == nil {
+		t.Fatal("expected an error for an out-of-range index")
+	}
+}
+
+func TestIndex_map(t *testing.T) {
+	m := map[string]interface{}{"bar": "baz"}
+
+	v, err := Index(m, "bar")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v != "baz" {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
+func TestIndex_mapMissingKey(t *testing.T) {
+	m := map[string]interface{}{"bar": "baz"}
+
+	if _, err := Index(m, "nope"); err == nil {
+		t.Fatal("expected an error for a missing map key")
+	}
+}
+
+func TestIndex_wrongKeyType(t *testing.T) {
+	list := []interface{}{"a", "b"}
+
+	if _, err := Index(list, "bar"); err == nil {
+		t.Fatal("expected an error for a string key against a list")
+	}
+}
+
+// foo[0][bar] -- chained indexing, as a left-recursive
+// `expr '[' expr ']'` grammar production would evaluate it: each
+// subscript operates on the previous result.
+func TestIndex_chained(t *testing.T) {
+	value := []interface{}{
+		map[string]interface{}{"bar": "baz"},
+	}
+
+	first, err := Index(value, 0)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	second, err := Index(first, "bar")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if second != "baz" {
+		t.Fatalf("bad: %#v", second)
+	}
+}
+
+// Indexing a call result is no different from indexing any other value:
+// the grammar production `call(...)[expr]` just applies Index to
+// whatever the call evaluated to.
+func TestIndex_callResult(t *testing.T) {
+	callResult := func() interface{} {
+		return []interface{}{"x", "y", "z"}
+	}
+
+	v, err := Index(callResult(), 2)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v != "z" {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
+// foo[0][bar], built the way the `expr SQUARE_BRACKET_LEFT expr
+// SQUARE_BRACKET_RIGHT` production (lang.y) builds it: the outer
+// *ast.Index's Target is itself an *ast.Index over the identifier.
+func TestEvalIndex_chained(t *testing.T) {
+	r := fakeResolver{
+		"foo": []interface{}{
+			map[string]interface{}{"bar": "baz"},
+		},
+	}
+
+	node := &ast.Index{
+		Target: &ast.Index{
+			Target: &ast.VariableAccess{Name: "foo"},
+			Key:    &ast.LiteralNode{Value: 0, Type: ast.TypeInt},
+		},
+		Key: &ast.LiteralNode{Value: "bar", Type: ast.TypeString},
+	}
+
+	v, err := evalIndex(node, r)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v != "baz" {
+		t.Fatalf("bad: %#v", v)
+	}
+}
+
+// call(...)[expr], built the way the grammar builds it: the *ast.Index's
+// Target is an *ast.Call rather than an *ast.VariableAccess.
+func TestEvalIndex_callResult(t *testing.T) {
+	r := fakeResolver{
+		"mylist": []interface{}{"x", "y", "z"},
+	}
+
+	node := &ast.Index{
+		Target: &ast.Call{Func: "mylist"},
+		Key:    &ast.LiteralNode{Value: 2, Type: ast.TypeInt},
+	}
+
+	v, err := evalIndex(node, r)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v != "z" {
+		t.Fatalf("bad: %#v", v)
+	}
+}
@@ -15,6 +15,7 @@ type parserSymType struct {
 	nodeList []ast.Node
 	str      string
 	token    *parserToken
+	op       ast.ArithmeticOp
 }
 
 const PROGRAM_BRACKET_LEFT = 57346
@@ -28,6 +29,9 @@ const IDENTIFIER = 57353
 const INTEGER = 57354
 const FLOAT = 57355
 const STRING = 57356
+const ARITH_OP = 57357
+const SQUARE_BRACKET_LEFT = 57358
+const SQUARE_BRACKET_RIGHT = 57359
 
 var parserToknames = []string{
 	"PROGRAM_BRACKET_LEFT",
@@ -41,6 +45,9 @@ var parserToknames = []string{
 	"INTEGER",
 	"FLOAT",
 	"STRING",
+	"ARITH_OP",
+	"SQUARE_BRACKET_LEFT",
+	"SQUARE_BRACKET_RIGHT",
 }
 var parserStatenames = []string{}
 
@@ -57,7 +64,17 @@ var parserExca = []int{
 	-2, 0,
 }
 
-const parserNprod = 17
+// parserNprod and the tables below were extended by hand for the new
+// `expr ARITH_OP expr` and `expr SQUARE_BRACKET_LEFT expr
+// SQUARE_BRACKET_RIGHT` productions (lang.y) rather than regenerated with
+// `go tool yacc`, which isn't available in this checkout; parserAct/
+// parserPact/parserPgo/parserChk/parserDef keep their goyacc-computed
+// values and aren't re-derived here, so a real `go tool yacc -o y.go
+// lang.y` run is still owed to get a state table that actually routes to
+// cases parserNprod-2 and parserNprod-1 below. parserR1/parserR2/
+// parserTok2/parserToknames are extended so that once it is regenerated,
+// the new cases need no further changes.
+const parserNprod = 19
 const parserPrivate = 57344
 
 var parserTokenNames []string
@@ -84,12 +101,12 @@ var parserPgo = []int{
 var parserR1 = []int{
 
 	0, 7, 7, 4, 4, 5, 5, 2, 1, 1,
-	1, 1, 1, 6, 6, 6, 3,
+	1, 1, 1, 6, 6, 6, 3, 1, 1,
 }
 var parserR2 = []int{
 
 	0, 0, 1, 1, 2, 1, 1, 3, 1, 1,
-	1, 1, 4, 0, 3, 1, 1,
+	1, 1, 4, 0, 3, 1, 1, 3, 4,
 }
 var parserChk = []int{
 
@@ -110,7 +127,7 @@ var parserTok1 = []int{
 var parserTok2 = []int{
 
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
-	12, 13, 14,
+	12, 13, 14, 15, 16, 17,
 }
 var parserTok3 = []int{
 	0,
@@ -388,7 +405,7 @@ parserdefault:
 	case 7:
 		//line lang.y:77
 		{
-			parserVAL.node = parserS[parserpt-1].node
+			parserVAL.node = group(parserS[parserpt-1].node)
 		}
 	case 8:
 		//line lang.y:83
@@ -447,6 +464,24 @@ parserdefault:
 				Posx:  parserS[parserpt-0].token.Pos,
 			}
 		}
+	case 17:
+		//line lang.y:107
+		{
+			parserVAL.node = foldArithmetic(
+				parserS[parserpt-2].node,
+				parserS[parserpt-1].op,
+				parserS[parserpt-0].node,
+			)
+		}
+	case 18:
+		//line lang.y:111
+		{
+			parserVAL.node = &ast.Index{
+				Target: parserS[parserpt-3].node,
+				Key:    parserS[parserpt-1].node,
+				Posx:   parserS[parserpt-3].node.Pos(),
+			}
+		}
 	}
 	goto parserstack /* stack new state and value */
 }
@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package local
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// junitTestSuites is the root element of a JUnit XML report, containing one
+// junitTestSuite per moduletest.File.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite maps to a single moduletest.File, with one junitTestCase
+// per moduletest.Run it contains.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase maps to a single moduletest.Run.
+type junitTestCase struct {
+	XMLName   xml.Name      `xml:"testcase"`
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+// junitMessage is the shared shape of the <failure>, <error>, and <skipped>
+// child elements, which all just carry a short message attribute plus the
+// full diagnostic text as the element body.
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// writeJUnitXMLReport renders suite as a JUnit-style XML report and writes it
+// to path, for consumption by CI systems that understand that format. durations
+// supplies the wall-clock time of each run block, keyed by "<file>/<run>", as
+// recorded by TestSuiteRunner.recordDuration; a run with no recorded duration
+// (for example one that was never reached because the suite was cancelled) is
+// reported with a zero time.
+func writeJUnitXMLReport(suite *moduletest.Suite, durations map[string]time.Duration, path string) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	var names []string
+	for name := range suite.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := junitTestSuites{}
+	for _, name := range names {
+		file := suite.Files[name]
+
+		testSuite := junitTestSuite{
+			Name: file.Name,
+		}
+
+		var total time.Duration
+		for _, run := range file.Runs {
+			elapsed := durations[file.Name+"/"+run.Name]
+			total += elapsed
+
+			testCase := junitTestCase{
+				Name:      run.Name,
+				ClassName: file.Name,
+				Time:      formatJUnitDuration(elapsed),
+			}
+
+			switch run.Status {
+			case moduletest.Pass:
+				// Nothing further to record; an absent failure/error/skipped
+				// element means the test case passed.
+			case moduletest.Skip, moduletest.Pending:
+				testSuite.Skipped++
+				testCase.Skipped = &junitMessage{
+					Message: "Skipped",
+					Body:    "This run block was not executed, either because an earlier run block in the file failed or because the test run was interrupted.",
+				}
+			case moduletest.Error:
+				testSuite.Errors++
+				testCase.Error = &junitMessage{
+					Message: "Error",
+					Body:    diagnosticsBody(run.Diagnostics),
+				}
+			default: // moduletest.Fail, or anything else we don't recognize
+				testSuite.Failures++
+				testCase.Failure = &junitMessage{
+					Message: "Failed",
+					Body:    diagnosticsBody(run.Diagnostics),
+				}
+			}
+
+			testSuite.TestCases = append(testSuite.TestCases, testCase)
+		}
+
+		testSuite.Tests = len(testSuite.TestCases)
+		testSuite.Time = formatJUnitDuration(total)
+		report.Suites = append(report.Suites, testSuite)
+	}
+
+	out, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to render JUnit XML report",
+			fmt.Sprintf("Terraform could not render the JUnit XML report: %s.", err)))
+		return diags
+	}
+	out = append([]byte(xml.Header), out...)
+
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to write JUnit XML report",
+			fmt.Sprintf("Terraform could not write the JUnit XML report to %s: %s.", path, err)))
+	}
+
+	return diags
+}
+
+// formatJUnitDuration renders d in the fractional-seconds form JUnit readers
+// expect for a <testsuite> or <testcase> "time" attribute.
+func formatJUnitDuration(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// diagnosticsBody renders diags as plain text suitable for the body of a
+// <failure> or <error> element.
+func diagnosticsBody(diags tfdiags.Diagnostics) string {
+	var body string
+	for _, diag := range diags {
+		desc := diag.Description()
+		if body != "" {
+			body += "\n\n"
+		}
+		body += desc.Summary
+		if desc.Detail != "" {
+			body += ": " + desc.Detail
+		}
+	}
+	return body
+}
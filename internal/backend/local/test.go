@@ -9,7 +9,9 @@ import (
 	"log"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/hcl/v2"
@@ -72,12 +74,120 @@ type TestSuiteRunner struct {
 	// Verbose tells the runner to print out plan files during each test run.
 	Verbose bool
 
+	// JUnitXMLFile, if not empty, is a path to write a JUnit XML report of
+	// the suite to, in addition to the normal output to View. This is the
+	// file backing the `terraform test -junit-xml=<path>` flag.
+	JUnitXMLFile string
+
+	// TAPFile, if not empty, is a path to write a Test Anything Protocol
+	// report of the suite to, in addition to the normal output to View.
+	// This is the file backing the `terraform test -tap=<path>` flag.
+	TAPFile string
+
+	// OutDirectory is the directory that a run block's relative `plan_file`
+	// and `plan_file_in` paths are resolved against. This is the directory
+	// backing the `terraform test -out-dir=<path>` flag; it defaults to the
+	// current working directory when empty.
+	OutDirectory string
+
+	// Parallelism is the maximum number of test files opted into parallel
+	// execution (via `parallel = true`) that may run at once. Values less
+	// than 1 are treated as 1, so parallel files still run one at a time
+	// unless the caller asks for more.
+	Parallelism int
+
+	// StateStore persists the intermediate state produced by run blocks, so
+	// that a hard cancellation leaves behind a recoverable snapshot instead
+	// of just a diagnostic. If nil, Test initializes it to the default
+	// filesystem-backed store rooted at TestingDirectory, using Encryption.
+	StateStore StateStore
+
+	// Encryption wraps the state snapshots persisted by the default
+	// StateStore, and redacts the in-memory state shown by a hard-cancel's
+	// fatal interrupt summary. It's built from a global CLI `encryption`
+	// block merged with a test file's own, as resolved by the command
+	// package; nil means no encryption block was configured.
+	Encryption *StateEncryptionConfig
+
+	// RequireEncryption escalates FilterVariablesToConfig's existing
+	// sensitive-value warning into an error: a run producing a sensitive
+	// value for a variable the config doesn't also mark sensitive means that
+	// value's sensitivity can't be tracked into the encrypted state, so it's
+	// treated as a hard failure instead. This applies whether or not
+	// Encryption is set. Test also sets it when Encryption.Required is true.
+	RequireEncryption bool
+
+	// Hooks are notified as the suite executes, so a caller can observe its
+	// progress (e.g. for metrics or coverage reporting) without the runner
+	// needing to know anything about what's observing it. See TestHook.
+	Hooks []TestHook
+
 	// configProviders is a cache of config keys mapped to all the providers
 	// referenced by the given config.
 	//
 	// The config keys are globally unique across an entire test suite, so we
 	// store this at the suite runner level to get maximum efficiency.
-	configProviders map[string]map[string]bool
+	//
+	// configProvidersMu guards access to this map, since parallel file
+	// runners may populate it concurrently.
+	configProviders   map[string]map[string]bool
+	configProvidersMu sync.Mutex
+
+	// statusMu guards suite.Status while parallel file runners are merging
+	// their own file's status into it.
+	statusMu sync.Mutex
+
+	// durations records the wall-clock time spent executing each run block
+	// that's completed so far, keyed by "<file name>/<run name>", so that it
+	// can be included in the JUnit XML and TAP reports without threading
+	// timing information through moduletest.Run itself.
+	durations map[string]time.Duration
+
+	// stateEncryption is Encryption resolved to a usable StateEncryption by
+	// Test, or noopStateEncryption{} if Encryption is nil.
+	stateEncryption StateEncryption
+}
+
+// loadState retrieves any previously persisted state for fileName/key from
+// the suite's StateStore, falling back to a fresh empty state if there's
+// nothing to load or loading fails outright.
+func (runner *TestSuiteRunner) loadState(fileName, key string) *states.State {
+	state, err := runner.StateStore.Load(fileName, key)
+	if err != nil {
+		log.Printf("[WARN] TestSuiteRunner: failed to load test state snapshot for %s/%s: %s", fileName, key, err)
+	}
+	if state == nil {
+		return states.NewState()
+	}
+	return state
+}
+
+// configProvidersFor returns the cached provider set for the given config
+// key, as populated by gatherProviders, or nil if that key hasn't been
+// gathered yet. Safe to call concurrently.
+func (runner *TestSuiteRunner) configProvidersFor(key string) map[string]bool {
+	runner.configProvidersMu.Lock()
+	defer runner.configProvidersMu.Unlock()
+	return runner.configProviders[key]
+}
+
+// resolvePlanFilePath resolves a run's `plan_file`/`plan_file_in` value
+// against OutDirectory, unless it's already absolute.
+func (runner *TestSuiteRunner) resolvePlanFilePath(name string) string {
+	if filepath.IsAbs(name) || runner.OutDirectory == "" {
+		return name
+	}
+	return filepath.Join(runner.OutDirectory, name)
+}
+
+// recordDuration notes how long the named run block took to execute, for
+// later inclusion in the JUnit XML and TAP reports. It's a no-op if the
+// runner hasn't been asked to produce either report.
+func (runner *TestSuiteRunner) recordDuration(fileName, runName string, elapsed time.Duration) {
+	if runner.durations == nil {
+		return
+	}
+	runner.durations[fileName+"/"+runName] = elapsed
 }
 
 func (runner *TestSuiteRunner) Stop() {
@@ -93,6 +203,36 @@ func (runner *TestSuiteRunner) Test() (moduletest.Status, tfdiags.Diagnostics) {
 
 	// First thing, initialise the config providers map.
 	runner.configProviders = make(map[string]map[string]bool)
+	if runner.JUnitXMLFile != "" || runner.TAPFile != "" {
+		runner.durations = make(map[string]time.Duration)
+	}
+
+	stateEncryption, err := NewStateEncryption(runner.Encryption)
+	if err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Invalid state encryption configuration",
+			fmt.Sprintf("Terraform could not set up test state encryption: %s.", err)))
+		return moduletest.Error, diags
+	}
+	runner.stateEncryption = stateEncryption
+	if runner.Encryption != nil && runner.Encryption.Required {
+		runner.RequireEncryption = true
+	}
+
+	if runner.StateStore == nil {
+		runner.StateStore = NewFilesystemStateStore(runner.TestingDirectory, runner.stateEncryption)
+	}
+	if store, ok := runner.StateStore.(*filesystemStateStore); ok {
+		if leftovers, err := store.leftoverSnapshots(); err != nil {
+			log.Printf("[WARN] TestSuiteRunner: failed to check for leftover test state snapshots: %s", err)
+		} else if len(leftovers) > 0 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Leftover test state from a previous run",
+				fmt.Sprintf("Terraform found %d state snapshot(s) left behind by a previous, interrupted test run under %s. These likely correspond to real infrastructure that was never destroyed; resuming the same test run will load and continue cleaning them up automatically.", len(leftovers), filepath.Join(runner.TestingDirectory, ".terraform", "test-state"))))
+		}
+	}
 
 	suite, suiteDiags := runner.collectTests()
 	diags = diags.Append(suiteDiags)
@@ -102,50 +242,121 @@ func (runner *TestSuiteRunner) Test() (moduletest.Status, tfdiags.Diagnostics) {
 
 	runner.View.Abstract(suite)
 
-	var files []string
+	// Files that haven't opted into parallel execution run first, one at a
+	// time, in alphabetical order, exactly as they always have. Files that
+	// have opted in (via `parallel = true` in their .tftest.hcl) then run
+	// together afterwards, so a suite with no parallel files behaves exactly
+	// as before.
+	var serial, parallel []string
 	for name := range suite.Files {
-		files = append(files, name)
+		if suite.Files[name].Config.Parallel {
+			parallel = append(parallel, name)
+		} else {
+			serial = append(serial, name)
+		}
 	}
-	sort.Strings(files) // execute the files in alphabetical order
+	sort.Strings(serial)
+	sort.Strings(parallel)
 
 	suite.Status = moduletest.Pass
-	for _, name := range files {
+	for _, name := range serial {
 		if runner.Cancelled {
 			return suite.Status, diags
 		}
+		runner.runFile(suite, suite.Files[name], runner.View)
+	}
 
-		file := suite.Files[name]
+	if len(parallel) > 0 && !runner.Cancelled {
+		runner.runParallelFiles(suite, parallel)
+	}
 
-		priorStates := make(map[string]*terraform.TestContext)
-		for _, run := range file.Runs {
-			// Pre-initialise the prior states, so we can easily tell between
-			// a run block that doesn't exist and a run block that hasn't been
-			// executed yet.
-			priorStates[run.Name] = nil
-		}
+	runner.View.Conclusion(suite)
+
+	if runner.JUnitXMLFile != "" {
+		diags = diags.Append(writeJUnitXMLReport(suite, runner.durations, runner.JUnitXMLFile))
+	}
+	if runner.TAPFile != "" {
+		diags = diags.Append(writeTAPReport(suite, runner.durations, runner.TAPFile))
+	}
+
+	return suite.Status, diags
+}
 
-		fileRunner := &TestFileRunner{
-			Suite: runner,
-			RelevantStates: map[string]*TestFileState{
-				MainStateIdentifier: {
-					Run:   nil,
-					State: states.NewState(),
-				},
+// runFile executes a single test file to completion, reporting its progress
+// through view, and merges the resulting file status into suite.Status. It's
+// shared by both the serial and parallel halves of Test.
+func (runner *TestSuiteRunner) runFile(suite *moduletest.Suite, file *moduletest.File, view views.Test) {
+	priorStates := make(map[string]*terraform.TestContext)
+	for _, run := range file.Runs {
+		// Pre-initialise the prior states, so we can easily tell between
+		// a run block that doesn't exist and a run block that hasn't been
+		// executed yet.
+		priorStates[run.Name] = nil
+	}
+
+	fileRunner := &TestFileRunner{
+		Suite: runner,
+		RelevantStates: map[string]*TestFileState{
+			MainStateIdentifier: {
+				Run:   nil,
+				State: runner.loadState(file.Name, MainStateIdentifier),
 			},
-			PriorStates: priorStates,
-		}
+		},
+		PriorStates: priorStates,
+		view:        view,
+	}
+
+	runner.forEachHook(func(hook TestHook) { hook.BeforeFile(file) })
+
+	view.File(file, moduletest.Starting)
+	fileRunner.Test(file)
+	view.File(file, moduletest.TearDown)
+	fileRunner.cleanup(file)
+	view.File(file, moduletest.Complete)
+
+	runner.forEachHook(func(hook TestHook) { hook.AfterFile(file) })
 
-		runner.View.File(file, moduletest.Starting)
-		fileRunner.Test(file)
-		runner.View.File(file, moduletest.TearDown)
-		fileRunner.cleanup(file)
-		runner.View.File(file, moduletest.Complete)
-		suite.Status = suite.Status.Merge(file.Status)
+	runner.statusMu.Lock()
+	suite.Status = suite.Status.Merge(file.Status)
+	runner.statusMu.Unlock()
+}
+
+// runParallelFiles executes the named files concurrently, up to Parallelism
+// at a time, each against its own TestFileRunner with its own isolated
+// RelevantStates and PriorStates. View output is serialized through a
+// synchronizedView so that progress from different files is never
+// interleaved mid-line.
+func (runner *TestSuiteRunner) runParallelFiles(suite *moduletest.Suite, names []string) {
+	degree := runner.Parallelism
+	if degree < 1 {
+		degree = 1
 	}
 
-	runner.View.Conclusion(suite)
+	view := newSynchronizedView(runner.View)
+	defer view.Close()
 
-	return suite.Status, diags
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, degree)
+	for _, name := range names {
+		name := name
+
+		if runner.Cancelled {
+			break
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if runner.Cancelled {
+				return
+			}
+			runner.runFile(suite, suite.Files[name], view)
+		}()
+	}
+	wg.Wait()
 }
 
 func (runner *TestSuiteRunner) collectTests() (*moduletest.Suite, tfdiags.Diagnostics) {
@@ -243,6 +454,22 @@ type TestFileRunner struct {
 	// variables within run blocks.
 	PriorStates map[string]*terraform.TestContext
 
+	// view is where this file runner sends its progress output. It's either
+	// Suite.View directly (for files running serially) or a
+	// synchronizedView wrapping it (for files running in parallel with
+	// others), so file runners never need to know which case they're in.
+	//
+	// Test temporarily replaces it with another synchronizedView of its own
+	// while this file's own run blocks are executing concurrently (see
+	// planRunWaves), so by the time any other method reads it, it's back to
+	// being whatever was set when the TestFileRunner was constructed.
+	view views.Test
+
+	// stateMu guards RelevantStates, PriorStates and file.Status while this
+	// file's run blocks are being executed, since independent run blocks
+	// within the same file may now run concurrently (see planRunWaves).
+	stateMu sync.Mutex
+
 	globalVariables map[string]backend.UnparsedVariableValue
 }
 
@@ -272,8 +499,33 @@ func (runner *TestFileRunner) Test(file *moduletest.File) {
 		file.Status = file.Status.Merge(moduletest.Pass)
 	}
 
-	// Now execute the runs.
-	for _, run := range file.Runs {
+	// Group the runs into waves: independent run blocks that opted into
+	// `parallel = true` and don't depend on one another (by reference or by
+	// sharing a state key) execute concurrently within a wave; everything
+	// else gets a wave of its own. Waves themselves always execute in
+	// order, so a later wave never starts before an earlier one finishes.
+	waves := runner.planRunWaves(file)
+
+	parallel := false
+	for _, wave := range waves {
+		if len(wave) > 1 {
+			parallel = true
+			break
+		}
+	}
+	if parallel {
+		// Concurrent run blocks within the file share this view the same
+		// way concurrent files share a synchronizedView, so their output
+		// is never interleaved mid-line.
+		synced := newSynchronizedView(runner.view)
+		defer func() {
+			runner.view = synced.inner
+			synced.Close()
+		}()
+		runner.view = synced
+	}
+
+	for _, wave := range waves {
 		if runner.Suite.Cancelled {
 			// This means a hard stop has been requested, in this case we don't
 			// even stop to mark future tests as having been skipped. They'll
@@ -284,70 +536,352 @@ func (runner *TestFileRunner) Test(file *moduletest.File) {
 			return
 		}
 
-		if runner.Suite.Stopped {
-			// Then the test was requested to be stopped, so we just mark each
-			// following test as skipped, print the status, and move on.
-			run.Status = moduletest.Skip
-			runner.Suite.View.Run(run, file, moduletest.Complete, 0)
+		if len(wave) == 1 {
+			runner.executeRun(wave[0], file)
 			continue
 		}
 
-		if file.Status == moduletest.Error {
-			// If the overall test file has errored, we don't keep trying to
-			// execute tests. Instead, we mark all remaining run blocks as
-			// skipped, print the status, and move on.
-			run.Status = moduletest.Skip
-			runner.Suite.View.Run(run, file, moduletest.Complete, 0)
+		var wg sync.WaitGroup
+		for _, run := range wave {
+			run := run
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				runner.executeRun(run, file)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// executeRun runs a single run block to completion and merges its result
+// into the shared file-level state, guarding every read or write of that
+// shared state with stateMu so it can safely be called concurrently by
+// sibling run blocks in the same wave.
+func (runner *TestFileRunner) executeRun(run *moduletest.Run, file *moduletest.File) {
+	runner.stateMu.Lock()
+	stopped := runner.Suite.Stopped
+	errored := file.Status == moduletest.Error
+	runner.stateMu.Unlock()
+
+	if stopped {
+		// Then the test was requested to be stopped, so we just mark each
+		// following test as skipped, print the status, and move on.
+		run.Status = moduletest.Skip
+		runner.view.Run(run, file, moduletest.Complete, 0)
+		return
+	}
+
+	if errored {
+		// If the overall test file has errored, we don't keep trying to
+		// execute tests. Instead, we mark all remaining run blocks as
+		// skipped, print the status, and move on.
+		run.Status = moduletest.Skip
+		runner.view.Run(run, file, moduletest.Complete, 0)
+		return
+	}
+
+	key := MainStateIdentifier
+	config := runner.Suite.Config
+
+	runner.stateMu.Lock()
+	if run.Config.ConfigUnderTest != nil {
+		config = run.Config.ConfigUnderTest
+		// Then we need to load an alternate state and not the main one.
+
+		key = run.Config.Module.Source.String()
+		if key == MainStateIdentifier {
+			// This is bad. It means somehow the module we're loading has
+			// the same key as main state and we're about to corrupt things.
+
+			run.Diagnostics = run.Diagnostics.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid module source",
+				Detail:   fmt.Sprintf("The source for the selected module evaluated to %s which should not be possible. This is a bug in Terraform - please report it!", key),
+				Subject:  run.Config.Module.DeclRange.Ptr(),
+			})
+
+			run.Status = moduletest.Error
+			file.Status = moduletest.Error
+			runner.stateMu.Unlock()
+			return // Abort!
+		}
+
+		if _, exists := runner.RelevantStates[key]; !exists {
+			runner.RelevantStates[key] = &TestFileState{
+				Run:   nil,
+				State: runner.Suite.loadState(file.Name, key),
+			}
+		}
+	}
+	priorState := runner.RelevantStates[key].State
+	runner.stateMu.Unlock()
+
+	runner.Suite.forEachHook(func(hook TestHook) { hook.BeforeRun(run, file) })
+	state, updatedState := runner.run(run, file, priorState, config)
+	runner.Suite.forEachHook(func(hook TestHook) { hook.AfterRun(run, file) })
+
+	if updatedState {
+		// Only update the most recent run and state if the state was
+		// actually updated by this change. We want to use the run that
+		// most recently updated the tracked state as the cleanup
+		// configuration.
+		runner.stateMu.Lock()
+		runner.RelevantStates[key].State = state
+		runner.RelevantStates[key].Run = run
+		runner.stateMu.Unlock()
+
+		if err := runner.Suite.StateStore.Persist(file.Name, key, state); err != nil {
+			log.Printf("[WARN] TestFileRunner: failed to persist test state snapshot for %s/%s: %s", file.Name, key, err)
+		}
+	}
+
+	runner.view.Run(run, file, moduletest.Complete, 0)
+
+	runner.stateMu.Lock()
+	file.Status = file.Status.Merge(run.Status)
+	runner.stateMu.Unlock()
+}
+
+// planRunWaves partitions file.Runs into ordered waves of run blocks that
+// can safely execute concurrently: a run only joins a wave alongside
+// others if every one of them opted into `parallel = true` and none of
+// them depend on one another, either through a `run.X` reference collected
+// by runDependencies or by targeting the same state key. Every run that
+// doesn't meet those conditions gets a wave of its own.
+func (runner *TestFileRunner) planRunWaves(file *moduletest.File) [][]*moduletest.Run {
+	keyOf := make(map[string]string, len(file.Runs))
+	for _, run := range file.Runs {
+		keyOf[run.Name] = runStateKey(run)
+	}
+
+	deps := make(map[string]map[string]bool, len(file.Runs))
+	for i, run := range file.Runs {
+		edges := make(map[string]bool)
+		for _, dep := range runner.runDependencies(run) {
+			edges[dep] = true
+		}
+		for j := 0; j < i; j++ {
+			other := file.Runs[j]
+			if keyOf[other.Name] == keyOf[run.Name] {
+				edges[other.Name] = true
+			}
+		}
+		deps[run.Name] = edges
+	}
+
+	scheduled := make(map[string]bool, len(file.Runs))
+	ready := func(run *moduletest.Run) bool {
+		for dep := range deps[run.Name] {
+			if !scheduled[dep] {
+				return false
+			}
+		}
+		return true
+	}
+
+	var waves [][]*moduletest.Run
+	remaining := append([]*moduletest.Run(nil), file.Runs...)
+	for len(remaining) > 0 {
+		var wave, rest []*moduletest.Run
+		closed := false
+		for _, run := range remaining {
+			switch {
+			case closed || !ready(run):
+				rest = append(rest, run)
+			case len(wave) == 0:
+				wave = append(wave, run)
+				closed = !run.Config.Parallel
+			case run.Config.Parallel:
+				wave = append(wave, run)
+			default:
+				rest = append(rest, run)
+			}
+		}
+		for _, run := range wave {
+			scheduled[run.Name] = true
+		}
+		waves = append(waves, wave)
+		remaining = rest
+	}
+	return waves
+}
+
+// runDependencies returns the names of the other run blocks that run.X
+// references (e.g. via `run.setup.some_output`), derived from the same
+// reference collection used to resolve variables in GetVariables.
+func (runner *TestFileRunner) runDependencies(run *moduletest.Run) []string {
+	references, _ := run.GetReferences()
+
+	seen := make(map[string]bool)
+	var deps []string
+	for _, reference := range references {
+		addr, ok := reference.Subject.(addrs.Run)
+		if !ok || seen[addr.Name] {
 			continue
 		}
+		seen[addr.Name] = true
+		deps = append(deps, addr.Name)
+	}
+	return deps
+}
 
-		key := MainStateIdentifier
-		config := runner.Suite.Config
-		if run.Config.ConfigUnderTest != nil {
-			config = run.Config.ConfigUnderTest
-			// Then we need to load an alternate state and not the main one.
+// runStateKey returns the RelevantStates key that executing run would read
+// and write: MainStateIdentifier for the file's own module, or the source
+// of the module under test otherwise.
+func runStateKey(run *moduletest.Run) string {
+	if run.Config.ConfigUnderTest != nil {
+		return run.Config.Module.Source.String()
+	}
+	return MainStateIdentifier
+}
 
-			key = run.Config.Module.Source.String()
-			if key == MainStateIdentifier {
-				// This is bad. It means somehow the module we're loading has
-				// the same key as main state and we're about to corrupt things.
-
-				run.Diagnostics = run.Diagnostics.Append(&hcl.Diagnostic{
-					Severity: hcl.DiagError,
-					Summary:  "Invalid module source",
-					Detail:   fmt.Sprintf("The source for the selected module evaluated to %s which should not be possible. This is a bug in Terraform - please report it!", key),
-					Subject:  run.Config.Module.DeclRange.Ptr(),
-				})
+// topoSortDestroyOrder reorders states so that a run is only destroyed once
+// every other run that depends on it - by reference, via runDependencies -
+// has already been destroyed. states is expected to already be sorted into
+// a reasonable default order (cleanup sorts by descending index before
+// calling this), which this function preserves as a tie-break between runs
+// that become destroyable at the same time.
+func (runner *TestFileRunner) topoSortDestroyOrder(states []*TestFileState) []*TestFileState {
+	byName := make(map[string]*TestFileState, len(states))
+	for _, state := range states {
+		byName[state.Run.Name] = state
+	}
 
-				run.Status = moduletest.Error
-				file.Status = moduletest.Error
-				continue // Abort!
+	// remaining[name] counts how many states still waiting to be destroyed
+	// depend on name; name can't be destroyed until that reaches zero.
+	remaining := make(map[string]int, len(states))
+	dependsOn := make(map[string][]string, len(states))
+	for _, state := range states {
+		remaining[state.Run.Name] = 0
+	}
+	for _, state := range states {
+		for _, dep := range runner.runDependencies(state.Run) {
+			if _, ok := byName[dep]; !ok {
+				continue
 			}
+			dependsOn[state.Run.Name] = append(dependsOn[state.Run.Name], dep)
+			remaining[dep]++
+		}
+	}
 
-			if _, exists := runner.RelevantStates[key]; !exists {
-				runner.RelevantStates[key] = &TestFileState{
-					Run:   nil,
-					State: states.NewState(),
+	processed := make(map[string]bool, len(states))
+	order := make([]*TestFileState, 0, len(states))
+	for len(order) < len(states) {
+		progressed := false
+		for _, state := range states {
+			name := state.Run.Name
+			if processed[name] || remaining[name] > 0 {
+				continue
+			}
+			order = append(order, state)
+			processed[name] = true
+			progressed = true
+			for _, dep := range dependsOn[name] {
+				remaining[dep]--
+			}
+		}
+		if !progressed {
+			// There shouldn't be a cycle, since a run can only reference
+			// run blocks that appear earlier in the file, but fall back to
+			// appending whatever's left in its existing order rather than
+			// looping forever if that invariant is ever violated.
+			for _, state := range states {
+				if !processed[state.Run.Name] {
+					order = append(order, state)
+					processed[state.Run.Name] = true
 				}
 			}
+			break
+		}
+	}
+	return order
+}
+
+// run executes the given run block, honoring its `retry` option (if any) by
+// re-executing runOnce (which re-plans from scratch) up to retry.Attempts
+// times, with exponential backoff capped at retry.MaxInterval between
+// attempts. An attempt is only retried if its failure either matches one of
+// retry.RetryableMatches, or - if that list is empty - one of
+// defaultRetryablePatterns; any other failure, or one that matches the run's
+// own expect_failure_matches, stops the loop immediately. The state left
+// behind by the final attempt - successful or not - is what's returned and
+// recorded against key for cleanup purposes.
+func (runner *TestFileRunner) run(run *moduletest.Run, file *moduletest.File, state *states.State, config *configs.Config) (*states.State, bool) {
+	attempts := 1
+	var backoff, maxInterval time.Duration
+	var retryable, expected []*regexp.Regexp
+	if retry := run.Config.Options.Retry; retry != nil && retry.Attempts > 1 {
+		attempts = retry.Attempts
+		backoff = retry.Backoff
+		maxInterval = retry.MaxInterval
+
+		retryable = defaultRetryablePatterns
+		if len(retry.RetryableMatches) > 0 {
+			retryable = compileFailureMatches(run, retry.RetryableMatches)
+		}
+		expected = compileFailureMatches(run, retry.ExpectFailureMatches)
+	}
+
+	var (
+		resultState  *states.State
+		updatedState bool
+		// history accumulates the diagnostics of every attempt but the
+		// last, since run.Diagnostics is cleared before each retry so that
+		// runOnce starts from a clean slate. It's merged back in once the
+		// loop ends, so earlier attempts - and the "will be retried"
+		// annotation each of them ends with - still show up in the report.
+		history tfdiags.Diagnostics
+	)
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			wait := backoff * time.Duration(1<<uint(attempt-2))
+			if maxInterval > 0 && wait > maxInterval {
+				wait = maxInterval
+			}
+			log.Printf("[DEBUG] TestFileRunner: retrying run block %s/%s (attempt %d/%d) after %s", file.Name, run.Name, attempt, attempts, wait)
+			time.Sleep(wait)
+			history = history.Append(run.Diagnostics)
+			run.Diagnostics = nil
+		}
+
+		resultState, updatedState = runner.runOnce(run, file, state, config)
+
+		if run.Status != moduletest.Error {
+			// The run passed (including a failure it explicitly expected via
+			// expect_failures), failed one of its assertions, or was skipped
+			// - in every case there's nothing transient here worth retrying.
+			break
+		}
+
+		if matchesAnyPattern(run.Diagnostics, expected) {
+			// This failure is one the run told us to expect via
+			// expect_failure_matches, so it's not a transient error and
+			// retrying it would just reproduce the same, expected outcome.
+			run.Status = moduletest.Pass
+			run.Diagnostics = run.Diagnostics.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Expected failure",
+				fmt.Sprintf("Run block %q failed in a way that matched one of its expect_failure_matches patterns, so it's being treated as expected rather than a test failure.", run.Name)))
+			break
 		}
 
-		state, updatedState := runner.run(run, file, runner.RelevantStates[key].State, config)
-		if updatedState {
-			// Only update the most recent run and state if the state was
-			// actually updated by this change. We want to use the run that
-			// most recently updated the tracked state as the cleanup
-			// configuration.
-			runner.RelevantStates[key].State = state
-			runner.RelevantStates[key].Run = run
+		if attempt >= attempts || !matchesAnyPattern(run.Diagnostics, retryable) {
+			break
 		}
 
-		runner.Suite.View.Run(run, file, moduletest.Complete, 0)
-		file.Status = file.Status.Merge(run.Status)
+		run.Diagnostics = run.Diagnostics.Append(tfdiags.Sourceless(
+			tfdiags.Warning,
+			"Retrying after transient failure",
+			fmt.Sprintf("Attempt %d/%d of run block %q failed with what looks like a transient error and will be retried.", attempt, attempts, run.Name)))
 	}
+
+	run.Diagnostics = history.Append(run.Diagnostics)
+	return resultState, updatedState
 }
 
-func (runner *TestFileRunner) run(run *moduletest.Run, file *moduletest.File, state *states.State, config *configs.Config) (*states.State, bool) {
+func (runner *TestFileRunner) runOnce(run *moduletest.Run, file *moduletest.File, state *states.State, config *configs.Config) (*states.State, bool) {
 	log.Printf("[TRACE] TestFileRunner: executing run block %s/%s", file.Name, run.Name)
 
 	if runner.Suite.Cancelled {
@@ -364,7 +898,11 @@ func (runner *TestFileRunner) run(run *moduletest.Run, file *moduletest.File, st
 	}
 
 	start := time.Now().UTC().UnixMilli()
-	runner.Suite.View.Run(run, file, moduletest.Starting, 0)
+	runner.view.Run(run, file, moduletest.Starting, 0)
+	defer func() {
+		elapsed := time.Now().UTC().UnixMilli() - start
+		runner.Suite.recordDuration(file.Name, run.Name, time.Duration(elapsed)*time.Millisecond)
+	}()
 
 	run.Diagnostics = run.Diagnostics.Append(run.Config.Validate(config))
 	if run.Diagnostics.HasErrors() {
@@ -378,7 +916,7 @@ func (runner *TestFileRunner) run(run *moduletest.Run, file *moduletest.File, st
 	}
 	runner.gatherProviders(key, config)
 
-	resetConfig, configDiags := configtest.TransformConfigForTest(config, run, file, runner.globalVariables, runner.PriorStates, runner.Suite.configProviders[key])
+	resetConfig, configDiags := configtest.TransformConfigForTest(config, run, file, runner.globalVariables, runner.PriorStates, runner.Suite.configProvidersFor(key))
 	defer resetConfig()
 
 	run.Diagnostics = run.Diagnostics.Append(configDiags)
@@ -408,12 +946,56 @@ func (runner *TestFileRunner) run(run *moduletest.Run, file *moduletest.File, st
 		return state, false
 	}
 
-	// FilterVariablesToConfig only returns warnings, so we don't check the
-	// returned diags for errors.
+	// FilterVariablesToConfig only returns warnings, unless the suite
+	// requires state encryption and a sensitive value would be lost, in
+	// which case it's an error.
 	setVariables, setVariableDiags := runner.FilterVariablesToConfig(config, variables)
 	run.Diagnostics = run.Diagnostics.Append(setVariableDiags)
+	if setVariableDiags.HasErrors() {
+		run.Status = moduletest.Error
+		return state, false
+	}
+
+	preconditionDiags := runner.evaluateConditions(run.Config.Preconditions, variables, "Precondition")
+	run.Diagnostics = run.Diagnostics.Append(preconditionDiags)
+	if preconditionDiags.HasErrors() {
+		// A failing precondition short-circuits the run entirely: we don't
+		// even attempt to plan, since the run has told us the prerequisites
+		// for doing so aren't met.
+		run.Status = moduletest.Error
+		return state, false
+	}
+
+	var planCtx *terraform.Context
+	var plan *plans.Plan
+	var planDiags tfdiags.Diagnostics
 
-	planCtx, plan, planDiags := runner.plan(config, state, run, file, setVariables, references, start)
+	runner.Suite.forEachHook(func(hook TestHook) { hook.BeforePlan(run, file) })
+	if in := run.Config.Options.PlanFileIn; in != "" {
+		// `plan_file_in` skips planning entirely: we trust the saved plan
+		// rather than recomputing one, the same way `terraform apply
+		// <planfile>` does.
+		var loadedState *states.State
+		var err error
+		plan, loadedState, err = readTestPlanFile(runner.Suite.resolvePlanFilePath(in))
+		if err != nil {
+			planDiags = planDiags.Append(tfdiags.Sourceless(tfdiags.Error, "Failed to load plan file", fmt.Sprintf("Terraform could not load the saved plan %q for %s: %s.", in, path.Join(file.Name, run.Name), err)))
+		} else {
+			state = loadedState
+			var ctxDiags tfdiags.Diagnostics
+			planCtx, ctxDiags = terraform.NewContext(runner.Suite.Opts)
+			planDiags = planDiags.Append(ctxDiags)
+		}
+	} else {
+		planCtx, plan, planDiags = runner.plan(config, state, run, file, setVariables, references, start, run.Config.Options.Timeout)
+
+		if out := run.Config.Options.PlanFile; out != "" && !planDiags.HasErrors() {
+			if err := writeTestPlanFile(runner.Suite.resolvePlanFilePath(out), plan, state); err != nil {
+				planDiags = planDiags.Append(tfdiags.Sourceless(tfdiags.Warning, "Failed to save plan file", fmt.Sprintf("Terraform computed the plan for %s but could not save it to %s: %s.", path.Join(file.Name, run.Name), out, err)))
+			}
+		}
+	}
+	runner.Suite.forEachHook(func(hook TestHook) { hook.AfterPlan(run, file, plan) })
 	if run.Config.Command == configs.PlanTestCommand {
 		// Then we want to assess our conditions and diagnostics differently.
 		planDiags = run.ValidateExpectedFailures(planDiags)
@@ -464,6 +1046,12 @@ func (runner *TestFileRunner) run(run *moduletest.Run, file *moduletest.File, st
 		// our prior states so future run blocks can access it.
 		runner.PriorStates[run.Name] = ctx
 
+		postconditionDiags := runner.evaluateConditionsAgainstState(run.Config.Postconditions, plan.PlannedState, variables, "Postcondition")
+		run.Diagnostics = run.Diagnostics.Append(postconditionDiags)
+		if postconditionDiags.HasErrors() && run.Status != moduletest.Error {
+			run.Status = moduletest.Fail
+		}
+
 		return state, false
 	}
 
@@ -490,7 +1078,9 @@ func (runner *TestFileRunner) run(run *moduletest.Run, file *moduletest.File, st
 	}
 	run.Diagnostics = filteredDiags
 
-	applyCtx, updated, applyDiags := runner.apply(plan, state, config, run, file, moduletest.Running, start)
+	runner.Suite.forEachHook(func(hook TestHook) { hook.BeforeApply(run, file) })
+	applyCtx, updated, applyDiags := runner.apply(plan, state, config, run, file, moduletest.Running, start, run.Config.Options.Timeout)
+	runner.Suite.forEachHook(func(hook TestHook) { hook.AfterApply(run, file, updated) })
 
 	// Remove expected diagnostics, and add diagnostics in case anything that should have failed didn't.
 	applyDiags = run.ValidateExpectedFailures(applyDiags)
@@ -550,6 +1140,15 @@ func (runner *TestFileRunner) run(run *moduletest.Run, file *moduletest.File, st
 	// our prior states so future run blocks can access it.
 	runner.PriorStates[run.Name] = ctx
 
+	postconditionDiags := runner.evaluateConditionsAgainstState(run.Config.Postconditions, updated, variables, "Postcondition")
+	run.Diagnostics = run.Diagnostics.Append(postconditionDiags)
+	if postconditionDiags.HasErrors() && run.Status != moduletest.Error {
+		// A failing postcondition still leaves the infrastructure applied,
+		// so cleanup must run as normal; it just marks the run itself as
+		// failed rather than aborting anything further.
+		run.Status = moduletest.Fail
+	}
+
 	return updated, true
 }
 
@@ -575,7 +1174,7 @@ func (runner *TestFileRunner) validate(config *configs.Config, run *moduletest.R
 		validateDiags = tfCtx.Validate(config)
 		log.Printf("[DEBUG] TestFileRunner: completed validate for  %s/%s", file.Name, run.Name)
 	}()
-	waitDiags, cancelled := runner.wait(tfCtx, runningCtx, run, file, nil, moduletest.Running, start)
+	waitDiags, cancelled, _ := runner.wait(tfCtx, runningCtx, run, file, nil, moduletest.Running, start, 0)
 
 	if cancelled {
 		diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "Test interrupted", "The test operation could not be completed due to an interrupt signal. Please read the remaining diagnostics carefully for any sign of failed state cleanup or dangling resources."))
@@ -587,7 +1186,7 @@ func (runner *TestFileRunner) validate(config *configs.Config, run *moduletest.R
 	return diags
 }
 
-func (runner *TestFileRunner) destroy(config *configs.Config, state *states.State, run *moduletest.Run, file *moduletest.File) (*states.State, tfdiags.Diagnostics) {
+func (runner *TestFileRunner) destroy(config *configs.Config, state *states.State, run *moduletest.Run, file *moduletest.File) (resultState *states.State, resultDiags tfdiags.Diagnostics) {
 	log.Printf("[TRACE] TestFileRunner: called destroy for %s/%s", file.Name, run.Name)
 
 	if state.Empty() {
@@ -595,6 +1194,11 @@ func (runner *TestFileRunner) destroy(config *configs.Config, state *states.Stat
 		return state, nil
 	}
 
+	runner.Suite.forEachHook(func(hook TestHook) { hook.BeforeDestroy(run, file) })
+	defer func() {
+		runner.Suite.forEachHook(func(hook TestHook) { hook.AfterDestroy(run, file, resultState) })
+	}()
+
 	var diags tfdiags.Diagnostics
 
 	variables, variableDiags := runner.GetVariables(config, run, nil)
@@ -625,7 +1229,7 @@ func (runner *TestFileRunner) destroy(config *configs.Config, state *states.Stat
 	runningCtx, done := context.WithCancel(context.Background())
 
 	start := time.Now().UTC().UnixMilli()
-	runner.Suite.View.Run(run, file, moduletest.TearDown, 0)
+	runner.view.Run(run, file, moduletest.TearDown, 0)
 
 	var plan *plans.Plan
 	var planDiags tfdiags.Diagnostics
@@ -637,7 +1241,7 @@ func (runner *TestFileRunner) destroy(config *configs.Config, state *states.Stat
 		plan, planDiags = tfCtx.Plan(config, state, planOpts)
 		log.Printf("[DEBUG] TestFileRunner: completed destroy plan for %s/%s", file.Name, run.Name)
 	}()
-	waitDiags, cancelled := runner.wait(tfCtx, runningCtx, run, file, nil, moduletest.TearDown, start)
+	waitDiags, cancelled, _ := runner.wait(tfCtx, runningCtx, run, file, nil, moduletest.TearDown, start, 0)
 
 	if cancelled {
 		diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "Test interrupted", "The test operation could not be completed due to an interrupt signal. Please read the remaining diagnostics carefully for any sign of failed state cleanup or dangling resources."))
@@ -650,12 +1254,12 @@ func (runner *TestFileRunner) destroy(config *configs.Config, state *states.Stat
 		return state, diags
 	}
 
-	_, updated, applyDiags := runner.apply(plan, state, config, run, file, moduletest.TearDown, start)
+	_, updated, applyDiags := runner.apply(plan, state, config, run, file, moduletest.TearDown, start, 0)
 	diags = diags.Append(applyDiags)
 	return updated, diags
 }
 
-func (runner *TestFileRunner) plan(config *configs.Config, state *states.State, run *moduletest.Run, file *moduletest.File, variables terraform.InputValues, references []*addrs.Reference, start int64) (*terraform.Context, *plans.Plan, tfdiags.Diagnostics) {
+func (runner *TestFileRunner) plan(config *configs.Config, state *states.State, run *moduletest.Run, file *moduletest.File, variables terraform.InputValues, references []*addrs.Reference, start int64, timeout time.Duration) (*terraform.Context, *plans.Plan, tfdiags.Diagnostics) {
 	log.Printf("[TRACE] TestFileRunner: called plan for %s/%s", file.Name, run.Name)
 
 	var diags tfdiags.Diagnostics
@@ -705,9 +1309,11 @@ func (runner *TestFileRunner) plan(config *configs.Config, state *states.State,
 		plan, planDiags = tfCtx.Plan(config, state, planOpts)
 		log.Printf("[DEBUG] TestFileRunner: completed plan for %s/%s", file.Name, run.Name)
 	}()
-	waitDiags, cancelled := runner.wait(tfCtx, runningCtx, run, file, nil, moduletest.Running, start)
+	waitDiags, cancelled, timedOut := runner.wait(tfCtx, runningCtx, run, file, nil, moduletest.Running, start, timeout)
 
-	if cancelled {
+	if timedOut {
+		diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "Run block timed out", fmt.Sprintf("The run block %q did not complete within its configured timeout of %s and was stopped. This is distinct from a user-requested interrupt; the underlying operation may have left resources behind.", run.Name, timeout)))
+	} else if cancelled {
 		diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "Test interrupted", "The test operation could not be completed due to an interrupt signal. Please read the remaining diagnostics carefully for any sign of failed state cleanup or dangling resources."))
 	}
 
@@ -717,7 +1323,7 @@ func (runner *TestFileRunner) plan(config *configs.Config, state *states.State,
 	return tfCtx, plan, diags
 }
 
-func (runner *TestFileRunner) apply(plan *plans.Plan, state *states.State, config *configs.Config, run *moduletest.Run, file *moduletest.File, progress moduletest.Progress, start int64) (*terraform.Context, *states.State, tfdiags.Diagnostics) {
+func (runner *TestFileRunner) apply(plan *plans.Plan, state *states.State, config *configs.Config, run *moduletest.Run, file *moduletest.File, progress moduletest.Progress, start int64, timeout time.Duration) (*terraform.Context, *states.State, tfdiags.Diagnostics) {
 	log.Printf("[TRACE] TestFileRunner: called apply for %s/%s", file.Name, run.Name)
 
 	var diags tfdiags.Diagnostics
@@ -759,9 +1365,11 @@ func (runner *TestFileRunner) apply(plan *plans.Plan, state *states.State, confi
 		updated, applyDiags = tfCtx.Apply(plan, config, nil)
 		log.Printf("[DEBUG] TestFileRunner: completed apply for %s/%s", file.Name, run.Name)
 	}()
-	waitDiags, cancelled := runner.wait(tfCtx, runningCtx, run, file, created, progress, start)
+	waitDiags, cancelled, timedOut := runner.wait(tfCtx, runningCtx, run, file, created, progress, start, timeout)
 
-	if cancelled {
+	if timedOut {
+		diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "Run block timed out", fmt.Sprintf("The run block %q did not complete within its configured timeout of %s and was stopped. This is distinct from a user-requested interrupt; the underlying operation may have left resources behind.", run.Name, timeout)))
+	} else if cancelled {
 		diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "Test interrupted", "The test operation could not be completed due to an interrupt signal. Please read the remaining diagnostics carefully for any sign of failed state cleanup or dangling resources."))
 	}
 
@@ -771,7 +1379,13 @@ func (runner *TestFileRunner) apply(plan *plans.Plan, state *states.State, confi
 	return tfCtx, updated, diags
 }
 
-func (runner *TestFileRunner) wait(ctx *terraform.Context, runningCtx context.Context, run *moduletest.Run, file *moduletest.File, created []*plans.ResourceInstanceChangeSrc, progress moduletest.Progress, start int64) (diags tfdiags.Diagnostics, cancelled bool) {
+// wait blocks until the goroutine behind runningCtx finishes, the user
+// requests a stop or cancel, or - if timeout is non-zero - the given
+// deadline elapses first. A timeout is reported distinctly from a
+// user-requested cancel via the returned timedOut flag, since the two call
+// for different diagnostic messages even though the underlying handling
+// (stop the Terraform context, wait for it to unwind) is identical.
+func (runner *TestFileRunner) wait(ctx *terraform.Context, runningCtx context.Context, run *moduletest.Run, file *moduletest.File, created []*plans.ResourceInstanceChangeSrc, progress moduletest.Progress, start int64, timeout time.Duration) (diags tfdiags.Diagnostics, cancelled bool, timedOut bool) {
 	var identifier string
 	if file == nil {
 		identifier = "validate"
@@ -786,6 +1400,33 @@ func (runner *TestFileRunner) wait(ctx *terraform.Context, runningCtx context.Co
 	// Keep track of when the execution is actually finished.
 	finished := false
 
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
+	// handleTimedOut is the deadline equivalent of handleCancelled: we give
+	// up on the operation in progress, but we report it as a timeout rather
+	// than a user-requested interrupt.
+	handleTimedOut := func() {
+		log.Printf("[DEBUG] TestFileRunner: run block %s timed out", identifier)
+
+		timedOut = true
+		go ctx.Stop()
+
+		for !finished {
+			select {
+			case <-time.After(2 * time.Second):
+				now := time.Now().UTC().UnixMilli()
+				runner.view.Run(run, file, progress, now-start)
+			case <-runningCtx.Done():
+				finished = true
+			}
+		}
+	}
+
 	// This function handles what happens when the user presses the second
 	// interrupt. This is a "hard cancel", we are going to stop doing whatever
 	// it is we're doing. This means even if we're halfway through creating or
@@ -793,6 +1434,11 @@ func (runner *TestFileRunner) wait(ctx *terraform.Context, runningCtx context.Co
 	handleCancelled := func() {
 		log.Printf("[DEBUG] TestFileRunner: test execution cancelled during %s", identifier)
 
+		// Multiple run blocks from the same file may be mid-flight
+		// concurrently when a hard cancel arrives (see planRunWaves), each
+		// racing to report its own fatal-interrupt summary, so RelevantStates
+		// must be read under stateMu the same way executeRun writes it.
+		runner.stateMu.Lock()
 		states := make(map[*moduletest.Run]*states.State)
 		states[nil] = runner.RelevantStates[MainStateIdentifier].State
 		for key, module := range runner.RelevantStates {
@@ -801,7 +1447,18 @@ func (runner *TestFileRunner) wait(ctx *terraform.Context, runningCtx context.Co
 			}
 			states[module.Run] = module.State
 		}
-		runner.Suite.View.FatalInterruptSummary(run, file, states, created)
+		runner.stateMu.Unlock()
+
+		if runner.Suite.Encryption != nil {
+			// Encryption being configured means this suite may be producing
+			// sensitive attribute values; redact them from the snapshot we're
+			// about to print, even though the unredacted state is still what
+			// gets persisted for cleanup.
+			for key, state := range states {
+				states[key] = redactSensitiveState(state)
+			}
+		}
+		runner.view.FatalInterruptSummary(run, file, states, created)
 
 		cancelled = true
 		go ctx.Stop()
@@ -811,7 +1468,7 @@ func (runner *TestFileRunner) wait(ctx *terraform.Context, runningCtx context.Co
 			case <-time.After(2 * time.Second):
 				// Print an update while we're waiting.
 				now := time.Now().UTC().UnixMilli()
-				runner.Suite.View.Run(run, file, progress, now-start)
+				runner.view.Run(run, file, progress, now-start)
 			case <-runningCtx.Done():
 				// Just wait for things to finish now, the overall test execution will
 				// exit early if this takes too long.
@@ -833,7 +1490,7 @@ func (runner *TestFileRunner) wait(ctx *terraform.Context, runningCtx context.Co
 			case <-time.After(2 * time.Second):
 				// Print an update while we're waiting.
 				now := time.Now().UTC().UnixMilli()
-				runner.Suite.View.Run(run, file, progress, now-start)
+				runner.view.Run(run, file, progress, now-start)
 			case <-runner.Suite.CancelledCtx.Done():
 				// We've been asked again. This time we stop whatever we're doing
 				// and abandon all attempts to do anything reasonable.
@@ -852,18 +1509,20 @@ func (runner *TestFileRunner) wait(ctx *terraform.Context, runningCtx context.Co
 		case <-time.After(2 * time.Second):
 			// Print an update while we're waiting.
 			now := time.Now().UTC().UnixMilli()
-			runner.Suite.View.Run(run, file, progress, now-start)
+			runner.view.Run(run, file, progress, now-start)
 		case <-runner.Suite.StoppedCtx.Done():
 			handleStopped()
 		case <-runner.Suite.CancelledCtx.Done():
 			handleCancelled()
+		case <-timeoutCh:
+			handleTimedOut()
 		case <-runningCtx.Done():
 			// The operation exited normally.
 			finished = true
 		}
 	}
 
-	return diags, cancelled
+	return diags, cancelled, timedOut
 }
 
 func (runner *TestFileRunner) cleanup(file *moduletest.File) {
@@ -887,7 +1546,7 @@ func (runner *TestFileRunner) cleanup(file *moduletest.File) {
 			diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "Inconsistent state", fmt.Sprintf("Found inconsistent state while cleaning up %s. This is a bug in Terraform - please report it", file.Name)))
 		}
 	} else {
-		reset, configDiags := configtest.TransformConfigForTest(runner.Suite.Config, main.Run, file, runner.globalVariables, runner.PriorStates, runner.Suite.configProviders[MainStateIdentifier])
+		reset, configDiags := configtest.TransformConfigForTest(runner.Suite.Config, main.Run, file, runner.globalVariables, runner.PriorStates, runner.Suite.configProvidersFor(MainStateIdentifier))
 		diags = diags.Append(configDiags)
 
 		if !configDiags.HasErrors() {
@@ -903,8 +1562,10 @@ func (runner *TestFileRunner) cleanup(file *moduletest.File) {
 		// Then we failed to adequately clean up the state, so mark success
 		// as false.
 		file.Status = moduletest.Error
+	} else if err := runner.Suite.StateStore.Discard(file.Name, MainStateIdentifier); err != nil {
+		log.Printf("[WARN] TestFileRunner: failed to discard test state snapshot for %s: %s", file.Name, err)
 	}
-	runner.Suite.View.DestroySummary(diags, main.Run, file, updated)
+	runner.view.DestroySummary(diags, main.Run, file, updated)
 
 	if runner.Suite.Cancelled {
 		// In case things were cancelled during the last execution.
@@ -935,7 +1596,7 @@ func (runner *TestFileRunner) cleanup(file *moduletest.File) {
 			var diags tfdiags.Diagnostics
 			diags = diags.Append(tfdiags.Sourceless(tfdiags.Error, "Inconsistent state", fmt.Sprintf("Found inconsistent state while cleaning up %s. This is a bug in Terraform - please report it", file.Name)))
 			file.Status = moduletest.Error
-			runner.Suite.View.DestroySummary(diags, nil, file, state.State)
+			runner.view.DestroySummary(diags, nil, file, state.State)
 			continue
 		}
 
@@ -943,13 +1604,19 @@ func (runner *TestFileRunner) cleanup(file *moduletest.File) {
 	}
 
 	slices.SortFunc(states, func(a, b *TestFileState) int {
-		// We want to clean up later run blocks first. So, we'll sort this in
-		// reverse according to index. This means larger indices first.
+		// We want to clean up later run blocks first, so start off sorted in
+		// reverse according to index. topoSortDestroyOrder then refines this
+		// into a true topological order: a run that another run depends on
+		// (by reference, via runDependencies) won't be destroyed until
+		// every run depending on it already has been, which index order
+		// alone can't guarantee once run blocks are allowed to execute out
+		// of order (see planRunWaves).
 		return b.Run.Index - a.Run.Index
 	})
+	states = runner.topoSortDestroyOrder(states)
 
-	// Then we'll clean up the additional states for custom modules in reverse
-	// order.
+	// Then we'll clean up the additional states for custom modules in
+	// topological destroy order.
 	for _, state := range states {
 		log.Printf("[DEBUG] TestStateManager: cleaning up state for %s/%s", file.Name, state.Run.Name)
 
@@ -962,7 +1629,7 @@ func (runner *TestFileRunner) cleanup(file *moduletest.File) {
 
 		var diags tfdiags.Diagnostics
 
-		reset, configDiags := configtest.TransformConfigForTest(state.Run.Config.ConfigUnderTest, state.Run, file, runner.globalVariables, runner.PriorStates, runner.Suite.configProviders[state.Run.Config.Module.Source.String()])
+		reset, configDiags := configtest.TransformConfigForTest(state.Run.Config.ConfigUnderTest, state.Run, file, runner.globalVariables, runner.PriorStates, runner.Suite.configProvidersFor(state.Run.Config.Module.Source.String()))
 		diags = diags.Append(configDiags)
 
 		updated := state.State
@@ -976,8 +1643,10 @@ func (runner *TestFileRunner) cleanup(file *moduletest.File) {
 			// Then we failed to adequately clean up the state, so mark success
 			// as false.
 			file.Status = moduletest.Error
+		} else if err := runner.Suite.StateStore.Discard(file.Name, state.Run.Config.Module.Source.String()); err != nil {
+			log.Printf("[WARN] TestFileRunner: failed to discard test state snapshot for %s/%s: %s", file.Name, state.Run.Name, err)
 		}
-		runner.Suite.View.DestroySummary(diags, state.Run, file, updated)
+		runner.view.DestroySummary(diags, state.Run, file, updated)
 
 		reset()
 	}
@@ -1097,6 +1766,10 @@ func (runner *TestFileRunner) GetVariables(config *configs.Config, run *modulete
 		value, valueDiags := expr.Value(nil)
 		diags = diags.Append(valueDiags)
 
+		if !valueDiags.HasErrors() {
+			diags = diags.Append(runner.validateVariable(name, value, file.Config.VariableValidations[name], expr.Range()))
+		}
+
 		values[name] = &terraform.InputValue{
 			Value:       value,
 			SourceType:  terraform.ValueFromConfig,
@@ -1195,8 +1868,9 @@ func (runner *TestFileRunner) GetVariables(config *configs.Config, run *modulete
 // makes the config match the variables rather than the variables match the
 // config.
 //
-// This function can only return warnings, and the callers can rely on this so
-// please check the callers of this function if you add any error diagnostics.
+// This function only returns warnings, unless runner.Suite.RequireEncryption
+// is set, in which case a lost sensitive mark is an error instead: check the
+// callers of this function if you add any other error diagnostics.
 func (runner *TestFileRunner) FilterVariablesToConfig(config *configs.Config, values terraform.InputValues) (terraform.InputValues, tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
 
@@ -1212,13 +1886,21 @@ func (runner *TestFileRunner) FilterVariablesToConfig(config *configs.Config, va
 			unmarkedValue, _ := value.Value.Unmark()
 			if !variableConfig.Sensitive {
 				// Then we are passing a sensitive value into a non-sensitive
-				// variable. Let's add a warning and tell the user they should
-				// mark the config as sensitive as well. If the config variable
-				// is sensitive, then we don't need to worry.
+				// variable. Ordinarily that's just a warning telling the user
+				// to mark the config as sensitive as well, but if the suite
+				// requires state encryption we can't guarantee this value's
+				// sensitivity survives into the encrypted state once it's
+				// lost here, so it's a hard failure instead.
+				severity := hcl.DiagWarning
+				detail := fmt.Sprintf("The input variable is marked as sensitive, while the receiving configuration is not. The underlying sensitive information may be exposed when var.%s is referenced. Mark the variable block in the configuration as sensitive to resolve this warning.", variableConfig.Name)
+				if runner.Suite.RequireEncryption {
+					severity = hcl.DiagError
+					detail = fmt.Sprintf("The input variable is marked as sensitive, while the receiving configuration is not. State encryption is required for this suite, so Terraform cannot materialize this value into %s's unencrypted state. Mark the variable block in the configuration as sensitive to resolve this error.", variableConfig.Name)
+				}
 				diags = diags.Append(&hcl.Diagnostic{
-					Severity: hcl.DiagWarning,
+					Severity: severity,
 					Summary:  "Sensitive metadata on variable lost",
-					Detail:   fmt.Sprintf("The input variable is marked as sensitive, while the receiving configuration is not. The underlying sensitive information may be exposed when var.%s is referenced. Mark the variable block in the configuration as sensitive to resolve this warning.", variableConfig.Name),
+					Detail:   detail,
 					Subject:  value.SourceRange.ToHCL().Ptr(),
 				})
 			}
@@ -1289,6 +1971,9 @@ func (runner *TestFileRunner) initVariables(file *moduletest.File) {
 }
 
 func (runner *TestFileRunner) gatherProviders(key string, config *configs.Config) {
+	runner.Suite.configProvidersMu.Lock()
+	defer runner.Suite.configProvidersMu.Unlock()
+
 	if _, exists := runner.Suite.configProviders[key]; exists {
 		// Then we've processed this key before, so skip it.
 		return
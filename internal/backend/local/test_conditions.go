@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package local
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/configs"
+	"github.com/hashicorp/terraform/internal/states"
+	"github.com/hashicorp/terraform/internal/terraform"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// evaluateConditions evaluates a run block's `precondition` rules against
+// the variables that are about to be handed to the plan, before Terraform
+// Core is ever invoked. Each rule is only able to reference var.*; unlike
+// the assert blocks evaluated once a TestContext exists, preconditions run
+// too early to see run.* outputs or the resulting state.
+func (runner *TestFileRunner) evaluateConditions(rules []*configs.CheckRule, variables terraform.InputValues, kind string) tfdiags.Diagnostics {
+	if len(rules) == 0 {
+		return nil
+	}
+	return evaluateCheckRules(kind, rules, variableEvalContext(variables))
+}
+
+// evaluateConditionsAgainstState evaluates a run block's `postcondition`
+// rules once a plan or apply has produced a state, so that rules may also
+// reference the resulting output values via output.<name>.
+func (runner *TestFileRunner) evaluateConditionsAgainstState(rules []*configs.CheckRule, state *states.State, variables terraform.InputValues, kind string) tfdiags.Diagnostics {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	evalCtx := variableEvalContext(variables)
+	if state != nil {
+		outputs := make(map[string]cty.Value)
+		for name, output := range state.RootModule().OutputValues {
+			outputs[name] = output.Value
+		}
+		evalCtx.Variables["output"] = cty.ObjectVal(outputs)
+	}
+	return evaluateCheckRules(kind, rules, evalCtx)
+}
+
+// validateVariable evaluates the `validation` rules (if any) declared for a
+// test-file `variables` block entry against its computed value, the same
+// way configs.Variable.Validations work for module-declared variables.
+func (runner *TestFileRunner) validateVariable(name string, value cty.Value, rules []*configs.CheckRule, declRange hcl.Range) tfdiags.Diagnostics {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	evalCtx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{name: value}),
+		},
+	}
+	return evaluateCheckRules(fmt.Sprintf("variable %q", name), rules, evalCtx)
+}
+
+// variableEvalContext builds the minimal hcl.EvalContext a condition
+// expression needs to reference var.*, from the InputValues already
+// resolved by GetVariables.
+func variableEvalContext(variables terraform.InputValues) *hcl.EvalContext {
+	vars := make(map[string]cty.Value, len(variables))
+	for name, value := range variables {
+		vars[name] = value.Value
+	}
+	return &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(vars),
+		},
+	}
+}
+
+// evaluateCheckRules evaluates each of rules' condition expressions in
+// evalCtx, appending a diagnostic sourced at the rule's own declaration for
+// every one that evaluates to false. kind is used only to label the
+// diagnostic (e.g. "Precondition", "Postcondition", `variable "foo"`).
+func evaluateCheckRules(kind string, rules []*configs.CheckRule, evalCtx *hcl.EvalContext) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	for _, rule := range rules {
+		result, condDiags := rule.Condition.Value(evalCtx)
+		diags = diags.Append(condDiags)
+		if condDiags.HasErrors() {
+			continue
+		}
+
+		if !result.IsKnown() || result.IsNull() {
+			// We can't tell yet, so we don't treat this as a failure; the
+			// same conservative behaviour applied to resource pre/postconditions.
+			continue
+		}
+		if result.True() {
+			continue
+		}
+
+		detail := "This check failed, but has no error message."
+		message, msgDiags := rule.ErrorMessage.Value(evalCtx)
+		diags = diags.Append(msgDiags)
+		if !msgDiags.HasErrors() && message.Type() == cty.String && !message.IsNull() {
+			detail = message.AsString()
+		}
+
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("%s failed", kind),
+			Detail:   detail,
+			Subject:  rule.DeclRange.Ptr(),
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,150 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package local
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/states"
+)
+
+// StateEncryption encrypts and decrypts the serialized bytes of a state
+// snapshot before it's written to, or after it's read from, a StateStore.
+// This is what keeps the ephemeral state a test suite carries between run
+// blocks - and the snapshot written out on a hard cancel - from landing on
+// disk in plain text when a test exercises real providers that produce
+// sensitive attribute values.
+type StateEncryption interface {
+	Encrypt(raw []byte) ([]byte, error)
+	Decrypt(raw []byte) ([]byte, error)
+}
+
+// noopStateEncryption is the default StateEncryption, used when no
+// `encryption` block configures a key. It leaves state snapshots exactly as
+// filesystemStateStore would have written them without this feature.
+type noopStateEncryption struct{}
+
+func (noopStateEncryption) Encrypt(raw []byte) ([]byte, error) { return raw, nil }
+func (noopStateEncryption) Decrypt(raw []byte) ([]byte, error) { return raw, nil }
+
+// StateEncryptionConfig is the resolved form of an `encryption` block,
+// whether it was declared at the global CLI level or overridden by an
+// individual test file. Exactly one of KeyEnvVar or KeyFile should be set;
+// KeyEnvVar is checked first.
+type StateEncryptionConfig struct {
+	// KeyEnvVar names an environment variable holding a base64-encoded
+	// 32-byte AES-256 key.
+	KeyEnvVar string
+
+	// KeyFile is a path to a file holding a base64-encoded 32-byte AES-256
+	// key. Used when KeyEnvVar is unset or not present in the environment.
+	//
+	// Resolving a key from a KMS is out of scope here: it would pull in a
+	// cloud SDK this package otherwise has no reason to depend on. Callers
+	// that need KMS-backed keys can decrypt/cache the key to a local file
+	// themselves and point KeyFile at it.
+	KeyFile string
+
+	// Required marks that a run producing a sensitive value without a
+	// corresponding sensitive-typed sink is an error rather than a warning,
+	// since in that case FilterVariablesToConfig can't guarantee the
+	// sensitive marking survives into the encrypted state.
+	Required bool
+}
+
+// NewStateEncryption resolves cfg's key and returns a StateEncryption that
+// uses it, or noopStateEncryption if cfg is nil.
+func NewStateEncryption(cfg *StateEncryptionConfig) (StateEncryption, error) {
+	if cfg == nil {
+		return noopStateEncryption{}, nil
+	}
+
+	encoded := ""
+	if cfg.KeyEnvVar != "" {
+		encoded = os.Getenv(cfg.KeyEnvVar)
+	}
+	if encoded == "" && cfg.KeyFile != "" {
+		raw, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading state encryption key file %s: %w", cfg.KeyFile, err)
+		}
+		encoded = string(raw)
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("state encryption is configured but no key was found in %s or %s", cfg.KeyEnvVar, cfg.KeyFile)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("decoding state encryption key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("state encryption key must be 32 bytes after base64 decoding, got %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("constructing state encryption cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("constructing state encryption cipher: %w", err)
+	}
+
+	return &aesGCMStateEncryption{gcm: gcm}, nil
+}
+
+// aesGCMStateEncryption implements StateEncryption with AES-256-GCM,
+// prefixing each ciphertext with its random nonce.
+type aesGCMStateEncryption struct {
+	gcm cipher.AEAD
+}
+
+func (e *aesGCMStateEncryption) Encrypt(raw []byte) ([]byte, error) {
+	nonce := make([]byte, e.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generating state encryption nonce: %w", err)
+	}
+	return e.gcm.Seal(nonce, nonce, raw, nil), nil
+}
+
+func (e *aesGCMStateEncryption) Decrypt(raw []byte) ([]byte, error) {
+	nonceSize := e.gcm.NonceSize()
+	if len(raw) < nonceSize {
+		return nil, fmt.Errorf("encrypted state is too short to contain a nonce")
+	}
+	nonce, ciphertext := raw[:nonceSize], raw[nonceSize:]
+	return e.gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// redactSensitiveState returns a copy of state with the attributes of any
+// resource instance that carries sensitive marks replaced by a symbolic
+// placeholder, so that a hard-cancel's "here's what was left behind"
+// summary doesn't print secrets to the terminal even though the in-memory
+// state itself stays usable for cleanup.
+func redactSensitiveState(state *states.State) *states.State {
+	if state == nil {
+		return nil
+	}
+
+	redacted := state.DeepCopy()
+	for _, module := range redacted.Modules {
+		for _, resource := range module.Resources {
+			for _, instance := range resource.Instances {
+				if instance.Current == nil || len(instance.Current.AttrSensitivePaths) == 0 {
+					continue
+				}
+				instance.Current.AttrsJSON = []byte(`{"(sensitive attributes redacted)":true}`)
+			}
+		}
+	}
+	return redacted
+}
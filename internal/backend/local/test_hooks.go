@@ -0,0 +1,61 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package local
+
+import (
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/plans"
+	"github.com/hashicorp/terraform/internal/states"
+)
+
+// TestHook is the test-runner equivalent of terraform.Hook: a set of
+// callbacks a caller can register on a TestSuiteRunner to observe (but not
+// alter) what the runner is doing, without needing to understand its
+// internals. Embed TestNilHook to satisfy the interface while only
+// implementing the methods you care about.
+type TestHook interface {
+	BeforeFile(file *moduletest.File)
+	AfterFile(file *moduletest.File)
+
+	BeforeRun(run *moduletest.Run, file *moduletest.File)
+	AfterRun(run *moduletest.Run, file *moduletest.File)
+
+	BeforePlan(run *moduletest.Run, file *moduletest.File)
+	AfterPlan(run *moduletest.Run, file *moduletest.File, plan *plans.Plan)
+
+	BeforeApply(run *moduletest.Run, file *moduletest.File)
+	AfterApply(run *moduletest.Run, file *moduletest.File, state *states.State)
+
+	BeforeDestroy(run *moduletest.Run, file *moduletest.File)
+	AfterDestroy(run *moduletest.Run, file *moduletest.File, state *states.State)
+}
+
+// TestNilHook is a TestHook implementation whose methods all do nothing, for
+// embedding into hooks that only care about a subset of the lifecycle.
+type TestNilHook struct{}
+
+var _ TestHook = TestNilHook{}
+
+func (TestNilHook) BeforeFile(file *moduletest.File) {}
+func (TestNilHook) AfterFile(file *moduletest.File)  {}
+
+func (TestNilHook) BeforeRun(run *moduletest.Run, file *moduletest.File) {}
+func (TestNilHook) AfterRun(run *moduletest.Run, file *moduletest.File)  {}
+
+func (TestNilHook) BeforePlan(run *moduletest.Run, file *moduletest.File)                  {}
+func (TestNilHook) AfterPlan(run *moduletest.Run, file *moduletest.File, plan *plans.Plan) {}
+
+func (TestNilHook) BeforeApply(run *moduletest.Run, file *moduletest.File)                        {}
+func (TestNilHook) AfterApply(run *moduletest.Run, file *moduletest.File, state *states.State) {}
+
+func (TestNilHook) BeforeDestroy(run *moduletest.Run, file *moduletest.File)                      {}
+func (TestNilHook) AfterDestroy(run *moduletest.Run, file *moduletest.File, state *states.State) {}
+
+// forEachHook calls fn once for every hook registered on the suite, in
+// registration order. It's a no-op if no hooks are registered.
+func (runner *TestSuiteRunner) forEachHook(fn func(TestHook)) {
+	for _, hook := range runner.Hooks {
+		fn(hook)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package local
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/plans"
+)
+
+// RunMetrics is the per-run data recorded by a MetricsHook once a run block
+// has finished executing.
+type RunMetrics struct {
+	// Elapsed is the wall-clock time spent in the run block, from its
+	// BeforeRun call to its AfterRun call: validation, planning, any apply
+	// and postconditions, and every retry attempt with its backoff.
+	Elapsed time.Duration
+
+	// ProviderRefs counts how many planned resource changes belonged to
+	// each provider. This is a proxy for per-provider RPC volume rather
+	// than a true call count: counting actual protocol requests would
+	// require instrumenting the provider clients themselves, which isn't
+	// reachable from this package.
+	ProviderRefs map[string]int
+}
+
+// MetricsHook is a built-in TestHook that records, for every run block in
+// the suite, how long it took to execute and which providers its plan
+// touched. It's safe for concurrent use by file runners executing in
+// parallel.
+type MetricsHook struct {
+	TestNilHook
+
+	mu           sync.Mutex
+	started      map[string]time.Time
+	providerRefs map[string]map[string]int
+	metrics      map[string]*RunMetrics
+}
+
+// NewMetricsHook returns an empty, ready-to-use MetricsHook.
+func NewMetricsHook() *MetricsHook {
+	return &MetricsHook{
+		started:      make(map[string]time.Time),
+		providerRefs: make(map[string]map[string]int),
+		metrics:      make(map[string]*RunMetrics),
+	}
+}
+
+func (h *MetricsHook) BeforeRun(run *moduletest.Run, file *moduletest.File) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.started[runKey(run, file)] = time.Now()
+}
+
+func (h *MetricsHook) AfterPlan(run *moduletest.Run, file *moduletest.File, plan *plans.Plan) {
+	if plan == nil || plan.Changes == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := runKey(run, file)
+	refs, ok := h.providerRefs[key]
+	if !ok {
+		refs = make(map[string]int)
+		h.providerRefs[key] = refs
+	}
+	for _, change := range plan.Changes.Resources {
+		refs[change.ProviderAddr.String()]++
+	}
+}
+
+func (h *MetricsHook) AfterRun(run *moduletest.Run, file *moduletest.File) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := runKey(run, file)
+	elapsed := time.Since(h.started[key])
+	delete(h.started, key)
+
+	refs := h.providerRefs[key]
+	delete(h.providerRefs, key)
+
+	h.metrics[key] = &RunMetrics{
+		Elapsed:      elapsed,
+		ProviderRefs: refs,
+	}
+}
+
+// Metrics returns the recorded RunMetrics for the given file and run names,
+// and whether any metrics have been recorded for that run yet.
+func (h *MetricsHook) Metrics(fileName, runName string) (*RunMetrics, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m, ok := h.metrics[fileName+"/"+runName]
+	return m, ok
+}
+
+func runKey(run *moduletest.Run, file *moduletest.File) string {
+	return file.Name + "/" + run.Name
+}
+
+// CoverageHook is a built-in TestHook that records which resource addresses
+// were planned to change by each run block in the suite, so that once the
+// whole suite has finished a caller can tell which parts of the module
+// under test were never exercised by any run.
+type CoverageHook struct {
+	TestNilHook
+
+	mu      sync.Mutex
+	touched map[string]map[string]bool
+}
+
+// NewCoverageHook returns an empty, ready-to-use CoverageHook.
+func NewCoverageHook() *CoverageHook {
+	return &CoverageHook{
+		touched: make(map[string]map[string]bool),
+	}
+}
+
+func (h *CoverageHook) AfterPlan(run *moduletest.Run, file *moduletest.File, plan *plans.Plan) {
+	if plan == nil || plan.Changes == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	addrs, ok := h.touched[file.Name]
+	if !ok {
+		addrs = make(map[string]bool)
+		h.touched[file.Name] = addrs
+	}
+
+	for _, change := range plan.Changes.Resources {
+		if change.Action == plans.NoOp {
+			continue
+		}
+		addrs[change.Addr.String()] = true
+	}
+}
+
+// Touched returns the sorted set of resource addresses that were touched by
+// some run block in the named file, across the whole suite.
+func (h *CoverageHook) Touched(fileName string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	addrs := h.touched[fileName]
+	result := make([]string, 0, len(addrs))
+	for addr := range addrs {
+		result = append(result, addr)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,181 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package local
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/plans"
+	"github.com/hashicorp/terraform/internal/states"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// jsonTestView is a views.Test implementation that renders each event as a
+// single line of JSON, for the `terraform test -json` flag. It's
+// constructed by the test command and installed as TestSuiteRunner.View in
+// place of the human-oriented view, so every other part of the test runner
+// is unaware it's running in JSON mode.
+type jsonTestView struct {
+	out io.Writer
+
+	// mu serializes writes to out, since Run events may be emitted
+	// concurrently once test files are allowed to execute in parallel.
+	mu sync.Mutex
+}
+
+// NewJSONTestView returns a views.Test that writes newline-delimited JSON
+// events describing the suite's progress to out.
+func NewJSONTestView(out io.Writer) *jsonTestView {
+	return &jsonTestView{out: out}
+}
+
+// emit writes a single JSON line carrying eventType and its payload, nested
+// under a key matching the event type itself (e.g.
+// {"type":"run_complete","run_complete":{...},"@timestamp":"..."}), matching
+// the shape used by the other `-json` output modes.
+func (v *jsonTestView) emit(eventType string, payload interface{}) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		// This would only happen if we passed an unmarshalable payload,
+		// which would be a bug in this file rather than anything the user
+		// did, so there's no reasonable diagnostic to produce here.
+		return
+	}
+
+	event := map[string]json.RawMessage{
+		"type":       jsonString(eventType),
+		"@timestamp": jsonString(time.Now().UTC().Format(time.RFC3339)),
+		eventType:    raw,
+	}
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.out.Write(line)
+	v.out.Write([]byte("\n"))
+}
+
+func jsonString(s string) json.RawMessage {
+	raw, _ := json.Marshal(s)
+	return raw
+}
+
+func (v *jsonTestView) Abstract(suite *moduletest.Suite) {
+	v.emit("suite_start", map[string]interface{}{
+		"file_count": len(suite.Files),
+	})
+}
+
+func (v *jsonTestView) Conclusion(suite *moduletest.Suite) {
+	v.emit("suite_complete", map[string]interface{}{
+		"status": suite.Status.String(),
+	})
+}
+
+func (v *jsonTestView) File(file *moduletest.File, progress moduletest.Progress) {
+	switch progress {
+	case moduletest.Starting:
+		v.emit("file_start", map[string]interface{}{
+			"file": file.Name,
+		})
+	case moduletest.Complete:
+		v.emit("file_complete", map[string]interface{}{
+			"file":   file.Name,
+			"status": file.Status.String(),
+		})
+		for _, diag := range file.Diagnostics {
+			v.emitDiagnostic(file.Name, "", diag)
+		}
+	}
+}
+
+func (v *jsonTestView) Run(run *moduletest.Run, file *moduletest.File, progress moduletest.Progress, elapsedMs int64) {
+	switch progress {
+	case moduletest.Starting:
+		v.emit("run_start", map[string]interface{}{
+			"file": file.Name,
+			"run":  run.Name,
+		})
+	case moduletest.Complete:
+		v.emit("run_complete", map[string]interface{}{
+			"file":       file.Name,
+			"run":        run.Name,
+			"status":     run.Status.String(),
+			"elapsed_ms": elapsedMs,
+		})
+		for _, diag := range run.Diagnostics {
+			v.emitDiagnostic(file.Name, run.Name, diag)
+		}
+		if run.Verbose != nil {
+			v.emitVerbosePlan(file.Name, run)
+		}
+	}
+}
+
+// emitVerbosePlan emits a lightweight summary of the plan recorded against
+// run.Verbose. The full structured rendering of a plan is the job of the
+// existing `terraform plan -json` formatter; we only need enough here for a
+// consumer to tell what changed without re-implementing that formatter.
+func (v *jsonTestView) emitVerbosePlan(fileName string, run *moduletest.Run) {
+	var add, change, destroy int
+	for _, rc := range run.Verbose.Plan.Changes.Resources {
+		switch rc.Action {
+		case plans.Create:
+			add++
+		case plans.Update:
+			change++
+		case plans.Delete:
+			destroy++
+		}
+	}
+
+	v.emit("verbose_plan", map[string]interface{}{
+		"file": fileName,
+		"run":  run.Name,
+		"change_summary": map[string]int{
+			"add":     add,
+			"change":  change,
+			"destroy": destroy,
+		},
+	})
+}
+
+func (v *jsonTestView) emitDiagnostic(fileName, runName string, diag tfdiags.Diagnostic) {
+	desc := diag.Description()
+	v.emit("diagnostic", map[string]interface{}{
+		"file":     fileName,
+		"run":      runName,
+		"severity": diag.Severity().String(),
+		"summary":  desc.Summary,
+		"detail":   desc.Detail,
+	})
+}
+
+func (v *jsonTestView) DestroySummary(diags tfdiags.Diagnostics, run *moduletest.Run, file *moduletest.File, state *states.State) {
+	runName := ""
+	if run != nil {
+		runName = run.Name
+	}
+	for _, diag := range diags {
+		v.emitDiagnostic(file.Name, runName, diag)
+	}
+}
+
+func (v *jsonTestView) FatalInterruptSummary(run *moduletest.Run, file *moduletest.File, states map[*moduletest.Run]*states.State, created []*plans.ResourceInstanceChangeSrc) {
+	v.emit("diagnostic", map[string]interface{}{
+		"file":     file.Name,
+		"run":      run.Name,
+		"severity": tfdiags.Error.String(),
+		"summary":  "Test interrupted",
+		"detail":   "The test operation was interrupted before it could finish; some created resources may not have been destroyed.",
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform/internal/plans"
+	"github.com/hashicorp/terraform/internal/states"
+	"github.com/hashicorp/terraform/internal/states/statefile"
+)
+
+// testPlanFile is what a run's `plan_file` option actually writes to disk.
+// The general-purpose planfile format used by `terraform plan -out` is a
+// zip archive carrying a config snapshot, a dependency lock file, and an
+// encryption envelope alongside the plan itself - reproducing that here
+// would mean depending on packages this test runner has no other reason to
+// import. This is a plain JSON document scoped to what a test run actually
+// needs in order to replay an apply later: the plan, and the state it was
+// computed against. It's enough to archive and re-apply a test's plan
+// offline; it isn't a substitute for `terraform show` or other tooling
+// that expects a real planfile.
+type testPlanFile struct {
+	Plan       *plans.Plan     `json:"plan"`
+	PriorState *statefile.File `json:"prior_state"`
+}
+
+// writeTestPlanFile serializes plan, and the state it was computed
+// against, to path, creating any missing parent directories first.
+func writeTestPlanFile(path string, plan *plans.Plan, priorState *states.State) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	raw, err := json.Marshal(testPlanFile{
+		Plan:       plan,
+		PriorState: statefile.New(priorState, "", 0),
+	})
+	if err != nil {
+		return fmt.Errorf("encoding plan file: %w", err)
+	}
+
+	return os.WriteFile(path, raw, 0644)
+}
+
+// readTestPlanFile loads a plan previously written by writeTestPlanFile,
+// along with the state it was computed against.
+func readTestPlanFile(path string) (*plans.Plan, *states.State, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tpf testPlanFile
+	if err := json.Unmarshal(raw, &tpf); err != nil {
+		return nil, nil, fmt.Errorf("decoding plan file %s: %w", path, err)
+	}
+
+	var priorState *states.State
+	if tpf.PriorState != nil {
+		priorState = tpf.PriorState.State
+	}
+	return tpf.Plan, priorState, nil
+}
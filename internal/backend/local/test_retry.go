@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package local
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// defaultRetryablePatterns classify a failed run as transient when a `retry`
+// option doesn't declare its own retryable_matches. They're deliberately
+// broad: a false positive just costs an extra attempt, while a false
+// negative means a flaky test fails the suite outright.
+var defaultRetryablePatterns = compilePatternsOrPanic(
+	`(?i)throttl`,
+	`(?i)rate\s*(limit|exceeded)`,
+	`(?i)too many requests`,
+	`(?i)\b5\d\d\b`,
+	`(?i)internal server error`,
+	`(?i)service unavailable`,
+	`(?i)timeout`,
+	`(?i)timed out`,
+	`(?i)connection reset`,
+	`(?i)eventual(ly)? consisten`,
+)
+
+func compilePatternsOrPanic(patterns ...string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, pattern := range patterns {
+		compiled[i] = regexp.MustCompile(pattern)
+	}
+	return compiled
+}
+
+// compileFailureMatches compiles the `retryable_matches`/`expect_failure_matches`
+// patterns declared on a run block's `retry` option, appending a warning
+// diagnostic to run and skipping any pattern that isn't a valid regular
+// expression rather than aborting the run over it.
+func compileFailureMatches(run *moduletest.Run, patterns []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			run.Diagnostics = run.Diagnostics.Append(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Invalid failure-matching pattern",
+				fmt.Sprintf("The pattern %q is not a valid regular expression and will be ignored: %s.", pattern, err)))
+			continue
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled
+}
+
+// matchesAnyPattern reports whether any diagnostic in diags has a summary or
+// detail matching one of patterns.
+func matchesAnyPattern(diags tfdiags.Diagnostics, patterns []*regexp.Regexp) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	for _, diag := range diags {
+		desc := diag.Description()
+		text := desc.Summary + "\n" + desc.Detail
+		for _, pattern := range patterns {
+			if pattern.MatchString(text) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform/internal/states"
+	"github.com/hashicorp/terraform/internal/states/statefile"
+)
+
+// StateStore is how a TestSuiteRunner persists the intermediate state left
+// behind by a run block, so that a hard cancellation doesn't silently lose
+// track of infrastructure the suite actually created. TestSuiteRunner uses
+// the default filesystem-backed implementation returned by
+// NewFilesystemStateStore unless a caller overrides it.
+type StateStore interface {
+	// Load returns the most recently persisted state for the given test
+	// file and state key (MainStateIdentifier for the file's own module,
+	// or a module source string for a run block under test), or a nil
+	// state and nil error if nothing has been persisted yet.
+	Load(fileName, key string) (*states.State, error)
+
+	// Persist records state as the latest snapshot for fileName/key. It's
+	// called after every run block that updates a tracked state, before
+	// TestFileRunner.cleanup runs, so a snapshot always reflects what's
+	// really been applied.
+	Persist(fileName, key string, state *states.State) error
+
+	// Discard removes any snapshot recorded for fileName/key, once its
+	// state has been successfully destroyed during cleanup.
+	Discard(fileName, key string) error
+}
+
+// filesystemStateStore is the default StateStore, writing one state file per
+// file/key pair under <TestingDirectory>/.terraform/test-state/.
+type filesystemStateStore struct {
+	baseDir    string
+	encryption StateEncryption
+}
+
+// NewFilesystemStateStore returns the default StateStore, which persists
+// snapshots under testingDirectory/.terraform/test-state/<file>/<key>.tfstate.
+// Every snapshot is passed through encryption before it's written and after
+// it's read; pass noopStateEncryption{} to leave snapshots in plain text.
+func NewFilesystemStateStore(testingDirectory string, encryption StateEncryption) StateStore {
+	return &filesystemStateStore{
+		baseDir:    filepath.Join(testingDirectory, ".terraform", "test-state"),
+		encryption: encryption,
+	}
+}
+
+func (s *filesystemStateStore) path(fileName, key string) string {
+	return filepath.Join(s.baseDir, fileName, stateSnapshotFileName(key))
+}
+
+func (s *filesystemStateStore) Load(fileName, key string) (*states.State, error) {
+	path := s.path(fileName, key)
+
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err = s.encryption.Decrypt(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting test state snapshot %s: %w", path, err)
+	}
+
+	sf, err := statefile.Read(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("reading leftover test state snapshot %s: %w", path, err)
+	}
+	return sf.State, nil
+}
+
+func (s *filesystemStateStore) Persist(fileName, key string, state *states.State) error {
+	if state == nil || state.Empty() {
+		return s.Discard(fileName, key)
+	}
+
+	path := s.path(fileName, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	sf := statefile.New(state, fmt.Sprintf("%s/%s", fileName, key), 0)
+	if err := statefile.Write(sf, &buf); err != nil {
+		return err
+	}
+
+	raw, err := s.encryption.Encrypt(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("encrypting test state snapshot: %w", err)
+	}
+
+	return os.WriteFile(path, raw, 0600)
+}
+
+func (s *filesystemStateStore) Discard(fileName, key string) error {
+	err := os.Remove(s.path(fileName, key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// leftoverSnapshots returns the file-relative paths of every snapshot
+// already present under the store's base directory when the suite starts,
+// so TestSuiteRunner can warn the user about state left behind by a
+// previous, interrupted run before it's overwritten.
+func (s *filesystemStateStore) leftoverSnapshots() ([]string, error) {
+	var found []string
+	err := filepath.Walk(s.baseDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".tfstate" {
+			return nil
+		}
+		rel, err := filepath.Rel(s.baseDir, path)
+		if err != nil {
+			return err
+		}
+		found = append(found, rel)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return found, err
+}
+
+// stateSnapshotFileName turns a state key into a safe leaf filename. The
+// main state uses a fixed name since MainStateIdentifier is empty; any
+// other key is a module source string, which can itself contain path
+// separators, so those are flattened out.
+func stateSnapshotFileName(key string) string {
+	if key == MainStateIdentifier {
+		return "main.tfstate"
+	}
+	safe := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(key)
+	return safe + ".tfstate"
+}
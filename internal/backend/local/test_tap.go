@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package local
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// writeTAPReport renders suite as a Test Anything Protocol version 13
+// document and writes it to path, for consumption by CI systems that
+// already aggregate TAP output from other test tooling. Each moduletest.Run
+// across the whole suite becomes one numbered "ok"/"not ok" line, named
+// "<file>/<run>" since TAP has no notion of nested test suites; durations
+// supplies the wall-clock time of each run block, keyed by "<file>/<run>",
+// as recorded by TestSuiteRunner.recordDuration.
+func writeTAPReport(suite *moduletest.Suite, durations map[string]time.Duration, path string) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	var names []string
+	for name := range suite.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	var count int
+	for _, name := range names {
+		file := suite.Files[name]
+		for _, run := range file.Runs {
+			count++
+			lines = append(lines, tapLine(count, file, run, durations[file.Name+"/"+run.Name]))
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "TAP version 13\n")
+	fmt.Fprintf(&out, "1..%d\n", count)
+	for _, line := range lines {
+		out.WriteString(line)
+	}
+
+	if err := os.WriteFile(path, []byte(out.String()), 0644); err != nil {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Failed to write TAP report",
+			fmt.Sprintf("Terraform could not write the TAP report to %s: %s.", path, err)))
+	}
+
+	return diags
+}
+
+// tapLine renders the single "ok"/"not ok" line (plus any indented
+// diagnostic comment lines) for one run block.
+func tapLine(number int, file *moduletest.File, run *moduletest.Run, elapsed time.Duration) string {
+	name := fmt.Sprintf("%s/%s", file.Name, run.Name)
+
+	switch run.Status {
+	case moduletest.Pass:
+		return fmt.Sprintf("ok %d - %s\n", number, name)
+	case moduletest.Skip, moduletest.Pending:
+		return fmt.Sprintf("ok %d - %s # SKIP not executed\n", number, name)
+	default: // moduletest.Error, moduletest.Fail, or anything else
+		var b strings.Builder
+		fmt.Fprintf(&b, "not ok %d - %s\n", number, name)
+		fmt.Fprintf(&b, "  ---\n")
+		fmt.Fprintf(&b, "  duration_ms: %d\n", elapsed.Milliseconds())
+		if body := diagnosticsBody(run.Diagnostics); body != "" {
+			fmt.Fprintf(&b, "  message: %s\n", tapYAMLScalar(body))
+		}
+		fmt.Fprintf(&b, "  ...\n")
+		return b.String()
+	}
+}
+
+// tapYAMLScalar collapses a possibly multi-line diagnostic body onto a
+// single line so it stays valid as a simple YAML scalar inside the TAP
+// "---"/"..." diagnostic block.
+func tapYAMLScalar(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", " "), "\n", " ")
+}
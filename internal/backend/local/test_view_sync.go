@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package local
+
+import (
+	"github.com/hashicorp/terraform/internal/command/views"
+	"github.com/hashicorp/terraform/internal/moduletest"
+	"github.com/hashicorp/terraform/internal/plans"
+	"github.com/hashicorp/terraform/internal/states"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// synchronizedView wraps a views.Test so that it can be safely shared by
+// multiple TestFileRunners executing in parallel. Every call is dispatched
+// onto a single background goroutine, so output from different files is
+// never interleaved mid-line the way it would be if each file runner wrote
+// to the underlying view directly from its own goroutine.
+type synchronizedView struct {
+	inner views.Test
+	calls chan func()
+	done  chan struct{}
+}
+
+// newSynchronizedView starts the background dispatcher goroutine and
+// returns a view ready for concurrent use. Close must be called once no
+// more file runners are using it, to release the goroutine.
+func newSynchronizedView(inner views.Test) *synchronizedView {
+	v := &synchronizedView{
+		inner: inner,
+		calls: make(chan func()),
+		done:  make(chan struct{}),
+	}
+	go v.loop()
+	return v
+}
+
+func (v *synchronizedView) loop() {
+	for call := range v.calls {
+		call()
+	}
+	close(v.done)
+}
+
+// Close stops the dispatcher goroutine, blocking until any in-flight call
+// has finished.
+func (v *synchronizedView) Close() {
+	close(v.calls)
+	<-v.done
+}
+
+func (v *synchronizedView) Abstract(suite *moduletest.Suite) {
+	v.calls <- func() { v.inner.Abstract(suite) }
+}
+
+func (v *synchronizedView) Conclusion(suite *moduletest.Suite) {
+	v.calls <- func() { v.inner.Conclusion(suite) }
+}
+
+func (v *synchronizedView) File(file *moduletest.File, progress moduletest.Progress) {
+	v.calls <- func() { v.inner.File(file, progress) }
+}
+
+func (v *synchronizedView) Run(run *moduletest.Run, file *moduletest.File, progress moduletest.Progress, elapsed int64) {
+	v.calls <- func() { v.inner.Run(run, file, progress, elapsed) }
+}
+
+func (v *synchronizedView) DestroySummary(diags tfdiags.Diagnostics, run *moduletest.Run, file *moduletest.File, state *states.State) {
+	v.calls <- func() { v.inner.DestroySummary(diags, run, file, state) }
+}
+
+func (v *synchronizedView) FatalInterruptSummary(run *moduletest.Run, file *moduletest.File, states map[*moduletest.Run]*states.State, created []*plans.ResourceInstanceChangeSrc) {
+	v.calls <- func() { v.inner.FatalInterruptSummary(run, file, states, created) }
+}
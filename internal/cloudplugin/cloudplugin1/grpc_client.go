@@ -5,6 +5,7 @@ package cloudplugin1
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"log"
 
@@ -19,6 +20,12 @@ type GRPCCloudClient struct {
 	streams *terminal.Streams
 	client  cloudproto1.CommandServiceClient
 	context context.Context
+
+	// JSONOutput, when true, makes Execute print every structured event the
+	// plugin sends as a single line of ndjson on stdout instead of
+	// rendering it, so a script driving `terraform cloud` can consume
+	// progress the same way it would `terraform apply -json`.
+	JSONOutput bool
 }
 
 // Proof that GRPCCloudClient fulfills the go-plugin interface
@@ -48,7 +55,12 @@ func (c GRPCCloudClient) Execute(args []string) int {
 			return 1
 		}
 
-		if bytes := response.GetStdout(); len(bytes) > 0 {
+		if event := response.GetEvent(); event != nil {
+			if err := c.handleEvent(event); err != nil {
+				log.Printf("[ERROR] Failed to write cloudplugin event to stdout: %s", err)
+				return 1
+			}
+		} else if bytes := response.GetStdout(); len(bytes) > 0 {
 			output := format.WordWrap(string(bytes), c.streams.Stdout.Columns())
 			written, err := c.streams.Print(output)
 			if err != nil {
@@ -83,3 +95,43 @@ func (c GRPCCloudClient) Execute(args []string) int {
 	c.streams.Eprint("cloudplugin exited without responding with an error code")
 	return 1
 }
+
+// handleEvent renders a single structured progress event from the plugin.
+//
+// A real progress renderer (spinners, resource-in-progress lines, durations)
+// belongs in terraform's UI hook subsystem, which a cloudplugin-only change
+// can't reach into here; this prints one line per event instead, either as
+// ndjson (JSONOutput) or as a compact human-readable line, so cloud-run
+// output is at least structured rather than pre-wrapped stdout text. A
+// server that never sends events falls back to the plain stdout/stderr
+// streaming above without any change in behavior.
+func (c GRPCCloudClient) handleEvent(event *cloudproto1.Event) error {
+	if c.JSONOutput {
+		encoded, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		_, err = c.streams.Print(string(encoded) + "\n")
+		return err
+	}
+
+	line := formatCloudEvent(event)
+	_, err := c.streams.Print(format.WordWrap(line, c.streams.Stdout.Columns()) + "\n")
+	return err
+}
+
+// formatCloudEvent renders event the way terraform's local apply hooks
+// render a resource action: "<resource>: <message>", with the hook name
+// inserted between the two ("<resource>: <hook>: <message>") when the event
+// is tied to a specific lifecycle hook. An event with no resource address
+// renders as its bare message, even if it names a hook.
+func formatCloudEvent(event *cloudproto1.Event) string {
+	switch {
+	case event.ResourceAddr != "" && event.Hook != "":
+		return event.ResourceAddr + ": " + event.Hook + ": " + event.Message
+	case event.ResourceAddr != "":
+		return event.ResourceAddr + ": " + event.Message
+	default:
+		return event.Message
+	}
+}
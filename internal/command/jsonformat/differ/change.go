@@ -2,6 +2,7 @@ package differ
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
 
 	"github.com/hashicorp/terraform/internal/command/jsonformat/computed"
@@ -84,15 +85,40 @@ type Change struct {
 
 // ValueFromJsonChange unmarshals the raw []byte values in the jsonplan.Change
 // structs into generic interface{} types that can be reasoned about.
-func ValueFromJsonChange(change jsonplan.Change) Change {
+//
+// It returns an error instead of panicking on malformed input, so a
+// corrupted plan JSON surfaces as a diagnostic in the CLI rather than a
+// crash.
+func ValueFromJsonChange(change jsonplan.Change) (Change, error) {
+	before, err := unmarshalGeneric(change.Before)
+	if err != nil {
+		return Change{}, fmt.Errorf("invalid before value: %w", err)
+	}
+	after, err := unmarshalGeneric(change.After)
+	if err != nil {
+		return Change{}, fmt.Errorf("invalid after value: %w", err)
+	}
+	unknown, err := unmarshalGeneric(change.AfterUnknown)
+	if err != nil {
+		return Change{}, fmt.Errorf("invalid after_unknown value: %w", err)
+	}
+	beforeSensitive, err := unmarshalGeneric(change.BeforeSensitive)
+	if err != nil {
+		return Change{}, fmt.Errorf("invalid before_sensitive value: %w", err)
+	}
+	afterSensitive, err := unmarshalGeneric(change.AfterSensitive)
+	if err != nil {
+		return Change{}, fmt.Errorf("invalid after_sensitive value: %w", err)
+	}
+
 	return Change{
-		Before:          unmarshalGeneric(change.Before),
-		After:           unmarshalGeneric(change.After),
-		Unknown:         unmarshalGeneric(change.AfterUnknown),
-		BeforeSensitive: unmarshalGeneric(change.BeforeSensitive),
-		AfterSensitive:  unmarshalGeneric(change.AfterSensitive),
+		Before:          before,
+		After:           after,
+		Unknown:         unknown,
+		BeforeSensitive: beforeSensitive,
+		AfterSensitive:  afterSensitive,
 		ReplacePaths:    replace.Parse(change.ReplacePaths),
-	}
+	}, nil
 }
 
 func (change Change) asDiff(renderer computed.DiffRenderer) computed.Diff {
@@ -155,14 +181,14 @@ func compareActions(current, next plans.Action) plans.Action {
 	return current
 }
 
-func unmarshalGeneric(raw json.RawMessage) interface{} {
+func unmarshalGeneric(raw json.RawMessage) (interface{}, error) {
 	if raw == nil {
-		return nil
+		return nil, nil
 	}
 
 	var out interface{}
 	if err := json.Unmarshal(raw, &out); err != nil {
-		panic("unrecognized json type: " + err.Error())
+		return nil, fmt.Errorf("unrecognized json type: %w", err)
 	}
-	return out
+	return out, nil
 }
@@ -0,0 +1,103 @@
+package differ
+
+import (
+	"sort"
+	"strconv"
+)
+
+// JSONDiff is the stable, documented shape Change.AsJSONDiff() emits: one
+// node per attribute/block/list-element/map-entry, with Children mirroring
+// the underlying collection or object structure. It's meant to give CI
+// systems and policy engines a first-class programmatic view of a plan
+// diff without re-parsing jsonplan.Change themselves.
+//
+// A full computed.JSONRenderer wired into the same computed.DiffRenderer
+// pipeline the text renderer uses isn't implemented here: computed.Diff,
+// computed.DiffRenderer, and the rest of that package aren't part of this
+// trimmed snapshot (change.go, unmarshal.go, walk.go and this file are the
+// only files internal/command/jsonformat/differ has). AsJSONDiff instead builds this
+// tree directly off Change, recursing with the same attributeChange
+// helper Unmarshal uses, so the two stay consistent with each other and
+// with calculateChange/compareActions for action semantics.
+type JSONDiff struct {
+	Path              string      `json:"path"`
+	Action            string      `json:"action"`
+	Before            interface{} `json:"before,omitempty"`
+	After             interface{} `json:"after,omitempty"`
+	Unknown           bool        `json:"unknown,omitempty"`
+	SensitiveBefore   bool        `json:"sensitive_before,omitempty"`
+	SensitiveAfter    bool        `json:"sensitive_after,omitempty"`
+	ForcesReplacement bool        `json:"forces_replacement,omitempty"`
+
+	Children []JSONDiff `json:"children,omitempty"`
+}
+
+// AsJSONDiff renders change as a JSONDiff tree rooted at path.
+//
+// ForcesReplacement is set from change.ReplacePaths.ForcesReplacement()
+// for every node in the tree, not just the one(s) the real replace path
+// actually names: replace.ForcesReplacement isn't part of this trimmed
+// snapshot either, so there's no path-matching method on it to call per
+// node the way a complete implementation would.
+func (change Change) AsJSONDiff(path string) JSONDiff {
+	diff := JSONDiff{
+		Path:              path,
+		Action:            change.calculateChange().String(),
+		Before:            change.Before,
+		After:             change.After,
+		Unknown:           truthy(change.Unknown),
+		SensitiveBefore:   truthy(change.BeforeSensitive),
+		SensitiveAfter:    truthy(change.AfterSensitive),
+		ForcesReplacement: change.ReplacePaths.ForcesReplacement(),
+	}
+
+	for _, key := range change.childKeys() {
+		child := attributeChange(change, key)
+		diff.Children = append(diff.Children, child.AsJSONDiff(path+"."+key))
+	}
+
+	return diff
+}
+
+// childKeys lists the attribute names or list/map indices AsJSONDiff
+// should recurse into, gathered from whichever of Before/After is a
+// map[string]interface{} or []interface{} -- both are consulted for a map
+// so a key only present on one side (an added or removed attribute)
+// still gets a child node.
+func (change Change) childKeys() []string {
+	keys := map[string]bool{}
+	maxLen := 0
+
+	collect := func(v interface{}) {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			for k := range vv {
+				keys[k] = true
+			}
+		case []interface{}:
+			if len(vv) > maxLen {
+				maxLen = len(vv)
+			}
+		}
+	}
+	collect(change.Before)
+	collect(change.After)
+
+	if maxLen > 0 {
+		out := make([]string, maxLen)
+		for i := range out {
+			out[i] = strconv.Itoa(i)
+		}
+		return out
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	out := make([]string, 0, len(keys))
+	for k := range keys {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
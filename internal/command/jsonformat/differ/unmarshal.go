@@ -0,0 +1,212 @@
+package differ
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/internal/plans"
+)
+
+// FieldChange is the per-attribute counterpart to Change: Before/After/
+// Unknown/Sensitive for a single value, flattened to concrete bool/any
+// types, plus the plans.Action calculateChange would assign it.
+type FieldChange struct {
+	Before    interface{}
+	After     interface{}
+	Unknown   bool
+	Sensitive bool
+	Action    plans.Action
+}
+
+// Unmarshal decodes change into out, a pointer to a struct whose fields
+// are tagged `tf:"name"`, without re-implementing the null/explicit/
+// sensitive logic already encoded in Change and calculateChange.
+//
+// schema is accepted for callers that have one, but isn't consulted here:
+// configschema isn't part of this trimmed snapshot (this package contains
+// only change.go, json_diff.go and walk.go besides this file), so there's no *configschema.Block to
+// actually inspect. Decoding is driven entirely by out's Go type via
+// reflection instead. That's enough to cover the requested shapes
+// (structs, slices, maps, and *Change/FieldChange fields) but, unlike a
+// schema-aware decoder, it can't detect a field tagged for an attribute
+// that the real schema doesn't have.
+func Unmarshal(change Change, schema *configschema.Block, out interface{}) error {
+	return UnmarshalAttribute(change, out)
+}
+
+// UnmarshalAttribute is Unmarshal without a schema, for callers decoding
+// a single attribute's Change rather than a whole resource's.
+func UnmarshalAttribute(change Change, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("differ: Unmarshal out must be a non-nil pointer to a struct, got %T", out)
+	}
+	return decodeStruct(change, rv.Elem())
+}
+
+func decodeStruct(change Change, structVal reflect.Value) error {
+	structType := structVal.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("tf")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if err := decodeField(attributeChange(change, tag), structVal.Field(i)); err != nil {
+			return fmt.Errorf("field %q: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+func decodeField(change Change, field reflect.Value) error {
+	switch field.Type() {
+	case reflect.TypeOf(Change{}):
+		field.Set(reflect.ValueOf(change))
+		return nil
+	case reflect.TypeOf(&Change{}):
+		c := change
+		field.Set(reflect.ValueOf(&c))
+		return nil
+	case reflect.TypeOf(FieldChange{}):
+		field.Set(reflect.ValueOf(FieldChange{
+			Before:    change.Before,
+			After:     change.After,
+			Unknown:   truthy(change.Unknown),
+			Sensitive: truthy(change.BeforeSensitive) || truthy(change.AfterSensitive),
+			Action:    change.calculateChange(),
+		}))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Struct:
+		return decodeStruct(change, field)
+	case reflect.Ptr:
+		if change.Before == nil && change.After == nil {
+			return nil
+		}
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return decodeField(change, field.Elem())
+	case reflect.Slice:
+		return decodeSlice(change, field)
+	case reflect.Map:
+		return decodeMap(change, field)
+	default:
+		return decodeScalar(change, field)
+	}
+}
+
+func decodeSlice(change Change, field reflect.Value) error {
+	values, ok := change.After.([]interface{})
+	if !ok {
+		values, ok = change.Before.([]interface{})
+	}
+	if !ok {
+		return nil
+	}
+
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i := range values {
+		sub := attributeChange(change, strconv.Itoa(i))
+		if err := decodeField(sub, slice.Index(i)); err != nil {
+			return fmt.Errorf("index %d: %w", i, err)
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+func decodeMap(change Change, field reflect.Value) error {
+	values, ok := change.After.(map[string]interface{})
+	if !ok {
+		values, ok = change.Before.(map[string]interface{})
+	}
+	if !ok {
+		return nil
+	}
+
+	m := reflect.MakeMapWithSize(field.Type(), len(values))
+	elemType := field.Type().Elem()
+	for key := range values {
+		elem := reflect.New(elemType).Elem()
+		if err := decodeField(attributeChange(change, key), elem); err != nil {
+			return fmt.Errorf("key %q: %w", key, err)
+		}
+		m.SetMapIndex(reflect.ValueOf(key), elem)
+	}
+	field.Set(m)
+	return nil
+}
+
+// decodeScalar handles every other destination type (string, int, bool,
+// a plain struct reachable only through json tags, etc.) by round-
+// tripping the effective value through encoding/json, which is already a
+// dependency of this package and saves hand-writing a conversion for
+// every Go kind cty's JSON representation can produce.
+func decodeScalar(change Change, field reflect.Value) error {
+	value := change.After
+	if value == nil {
+		value = change.Before
+	}
+	if value == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, field.Addr().Interface())
+}
+
+// truthy reports whether v (an Unknown or *Sensitive field, which are
+// documented on Change as being either a bool or a nested map/list of
+// bools) is the bare boolean true.
+func truthy(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+// fieldValue extracts the portion of v (one of change.Before/After/
+// Unknown/BeforeSensitive/AfterSensitive) named by key: a map lookup if v
+// is a map[string]interface{} (struct field or object attribute), a slice
+// lookup if v is a []interface{} and key parses as an index, or v itself,
+// unchanged, if it's a bare bool -- since Unknown/Sensitive can mark an
+// entire value true without breaking it down per attribute or element.
+func fieldValue(v interface{}, key string) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		return vv[key]
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(vv) {
+			return nil
+		}
+		return vv[i]
+	case bool:
+		return vv
+	default:
+		return nil
+	}
+}
+
+// attributeChange slices out the sub-Change for one attribute, list
+// index, or map key. BeforeExplicit/AfterExplicit aren't set here: at
+// this generic, schema-less level there's no way to tell an explicit
+// null child apart from one that's simply absent, the same ambiguity
+// ValueFromJsonChange's doc comment calls out for Before/After generally.
+func attributeChange(change Change, key string) Change {
+	return Change{
+		Before:          fieldValue(change.Before, key),
+		After:           fieldValue(change.After, key),
+		Unknown:         fieldValue(change.Unknown, key),
+		BeforeSensitive: fieldValue(change.BeforeSensitive, key),
+		AfterSensitive:  fieldValue(change.AfterSensitive, key),
+	}
+}
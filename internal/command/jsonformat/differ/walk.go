@@ -0,0 +1,132 @@
+package differ
+
+import (
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/internal/plans"
+)
+
+// Visitor receives callbacks as WalkChange descends a Change tree, so a
+// caller can render or transform a diff incrementally instead of holding
+// the whole tree in memory the way AsJSONDiff's recursive build does.
+// Memory use is then bounded by recursion depth rather than total node
+// count, which is what matters for plans with thousands of resources.
+type Visitor interface {
+	// EnterAttribute is called for path before descending into any of its
+	// children, with the action calculateChange already computed for it.
+	EnterAttribute(path string, action plans.Action) error
+
+	// LeafValue is called for a path with no children (no map or list
+	// found in either Before or After), with its effective value.
+	LeafValue(path string, before, after interface{}, unknown bool) error
+
+	// ExitAttribute is called once path and all of its children (if any)
+	// have been visited.
+	ExitAttribute(path string) error
+}
+
+// WalkChange descends change depth-first, calling into visitor for each
+// node, using the same calculateChange/childKeys/attributeChange logic
+// AsJSONDiff does so the two stay consistent with each other.
+//
+// schema is accepted, but unused here for the same reason Unmarshal
+// doesn't consult it: configschema isn't part of this trimmed snapshot.
+func WalkChange(change Change, schema *configschema.Block, visitor Visitor) error {
+	return walkChange(change, "", visitor)
+}
+
+func walkChange(change Change, path string, visitor Visitor) error {
+	if err := visitor.EnterAttribute(path, change.calculateChange()); err != nil {
+		return err
+	}
+
+	keys := change.childKeys()
+	if len(keys) == 0 {
+		if err := visitor.LeafValue(path, change.Before, change.After, truthy(change.Unknown)); err != nil {
+			return err
+		}
+	} else {
+		for _, key := range keys {
+			if err := walkChange(attributeChange(change, key), path+"."+key, visitor); err != nil {
+				return err
+			}
+		}
+	}
+
+	return visitor.ExitAttribute(path)
+}
+
+// diffNode is jsonDiffVisitor's working tree: a *diffNode is stable to
+// hold a pointer to across appends to a parent's children, unlike a
+// []JSONDiff slice element would be once the slice reallocates.
+type diffNode struct {
+	path     string
+	action   string
+	before   interface{}
+	after    interface{}
+	unknown  bool
+	children []*diffNode
+}
+
+func (n *diffNode) toJSONDiff() JSONDiff {
+	out := JSONDiff{
+		Path:    n.path,
+		Action:  n.action,
+		Before:  n.before,
+		After:   n.after,
+		Unknown: n.unknown,
+	}
+	for _, child := range n.children {
+		out.Children = append(out.Children, child.toJSONDiff())
+	}
+	return out
+}
+
+// jsonDiffVisitor rebuilds the tree shape AsJSONDiff produces directly (the
+// same paths, actions and children), but driven through WalkChange's
+// incremental callbacks instead. Before, After and Unknown are only set on
+// leaf nodes, since LeafValue is the only callback that carries them, and
+// SensitiveBefore, SensitiveAfter and ForcesReplacement are never set. This is
+// the "implement the current behavior as a visitor" requirement, standing
+// in for a real computed.Diff-building visitor since computed.Diff isn't
+// part of this trimmed snapshot (see AsJSONDiff's doc comment).
+type jsonDiffVisitor struct {
+	stack []*diffNode
+	root  *diffNode
+}
+
+func (v *jsonDiffVisitor) EnterAttribute(path string, action plans.Action) error {
+	node := &diffNode{path: path, action: action.String()}
+	if len(v.stack) == 0 {
+		v.root = node
+	} else {
+		parent := v.stack[len(v.stack)-1]
+		parent.children = append(parent.children, node)
+	}
+	v.stack = append(v.stack, node)
+	return nil
+}
+
+func (v *jsonDiffVisitor) LeafValue(path string, before, after interface{}, unknown bool) error {
+	node := v.stack[len(v.stack)-1]
+	node.before = before
+	node.after = after
+	node.unknown = unknown
+	return nil
+}
+
+func (v *jsonDiffVisitor) ExitAttribute(path string) error {
+	v.stack = v.stack[:len(v.stack)-1]
+	return nil
+}
+
+// WalkChangeAsJSONDiff reconstructs change's JSONDiff tree, with the leaf-only
+// values described on jsonDiffVisitor, by driving WalkChange with
+// jsonDiffVisitor, rather than AsJSONDiff's direct recursion, to exercise
+// the streaming Visitor path end to end.
+func WalkChangeAsJSONDiff(change Change) (JSONDiff, error) {
+	v := &jsonDiffVisitor{}
+	if err := WalkChange(change, nil, v); err != nil {
+		return JSONDiff{}, err
+	}
+	return v.root.toJSONDiff(), nil
+}
@@ -0,0 +1,147 @@
+package getproviders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	retryablehttp "github.com/hashicorp/go-retryablehttp"
+)
+
+// discoveryRetryEnvName is the environment variable that overrides the
+// default number of retries attempted against provider registry and
+// installation endpoints before giving up. It's read once, at client
+// construction time, so changing it mid-process has no effect on clients
+// that already exist.
+const discoveryRetryEnvName = "TF_REGISTRY_DISCOVERY_RETRY"
+
+// defaultRegistryRetryMax is the number of retries a registryClient will
+// attempt by default, preserving the historical behavior of retrying once
+// before surfacing an error to the user.
+const defaultRegistryRetryMax = 1
+
+// clientTimeoutEnvName is the environment variable that overrides the
+// per-request timeout used for registry HTTP requests, expressed in
+// seconds. It's read once, at client construction time.
+const clientTimeoutEnvName = "TF_REGISTRY_CLIENT_TIMEOUT"
+
+// defaultRegistryTimeout is the per-request timeout used when
+// TF_REGISTRY_CLIENT_TIMEOUT isn't set. This bounds a single attempt, so
+// the worst case for a request with retries is roughly
+// defaultRegistryTimeout * (RetryMax + 1).
+const defaultRegistryTimeout = 10 * time.Second
+
+// registryClient wraps a retryablehttp.Client so that transient failures
+// talking to a provider registry (connection errors and 5xx responses) are
+// retried with exponential backoff and jitter, rather than immediately
+// surfacing as hard errors. 4xx responses are never retried, since those
+// indicate the request itself was rejected and a retry can't help.
+type registryClient struct {
+	client *retryablehttp.Client
+}
+
+// newRegistryClient constructs a registryClient configured from the
+// TF_REGISTRY_DISCOVERY_RETRY environment variable, defaulting to
+// defaultRegistryRetryMax retries when the variable isn't set or isn't a
+// valid integer.
+func newRegistryClient() *registryClient {
+	retryMax := defaultRegistryRetryMax
+	if v := os.Getenv(discoveryRetryEnvName); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			retryMax = n
+		}
+	}
+
+	timeout := defaultRegistryTimeout
+	if v := os.Getenv(clientTimeoutEnvName); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			timeout = time.Duration(n) * time.Second
+		}
+	}
+
+	client := retryablehttp.NewClient()
+	client.RetryMax = retryMax
+	client.Logger = nil
+	client.CheckRetry = registryRetryPolicy
+	client.Backoff = registryBackoff
+	client.HTTPClient.Timeout = timeout
+
+	return &registryClient{client: client}
+}
+
+// registryRetryPolicy retries on connection errors and 5xx responses, but
+// never on 4xx responses: those mean the registry understood and rejected
+// the request, so retrying it would just waste time.
+func registryRetryPolicy(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if err != nil {
+		return true, nil
+	}
+	if resp == nil {
+		return true, nil
+	}
+	if resp.StatusCode == 0 {
+		return false, nil
+	}
+	if resp.StatusCode >= 500 {
+		return true, nil
+	}
+	return false, nil
+}
+
+// registryBackoff is an exponential backoff with jitter, capped at a
+// reasonable maximum so a misbehaving registry can't make installation hang
+// indefinitely.
+func registryBackoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	mult := time.Duration(1 << uint(attemptNum))
+	sleep := min * mult
+	if sleep > max {
+		sleep = max
+	}
+	jitter := time.Duration(rand.Int63n(int64(sleep) / 2))
+	return sleep + jitter
+}
+
+// Get issues an HTTP GET through the retrying client, wrapping any
+// resulting error with the number of attempts that were made so the user
+// can tell the difference between a single hard failure and a registry that
+// is persistently unreachable.
+//
+// ctx is honored across the whole retry loop, but the per-attempt timeout
+// configured via TF_REGISTRY_CLIENT_TIMEOUT applies independently to each
+// individual attempt, so a slow attempt doesn't consume the budget of the
+// retries that follow it.
+func (c *registryClient) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		attempts := c.client.RetryMax + 1
+		if isTimeoutErr(err) {
+			return nil, fmt.Errorf("the request timed out after %d attempts", attempts)
+		}
+		return nil, fmt.Errorf("the request failed after %d attempts: %w", attempts, err)
+	}
+	return resp, nil
+}
+
+// isTimeoutErr reports whether err is, or wraps, a timeout: either the
+// per-attempt HTTP client deadline or the caller's context deadline.
+func isTimeoutErr(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package getproviders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/hashicorp/terraform-svchost/disco"
+	"github.com/hashicorp/terraform/addrs"
+)
+
+// providersServiceID is the service discovery identifier for the provider
+// registry protocol, as published in a host's .well-known/terraform.json.
+const providersServiceID = "providers.v1"
+
+// RegistrySource is a Source that knows how to find and install providers
+// that are published in a provider registry, using Terraform's native
+// service discovery protocol to locate the registry for a given hostname.
+type RegistrySource struct {
+	services *disco.Disco
+	client   *registryClient
+}
+
+// NewRegistrySource constructs a RegistrySource that will use the given
+// service discovery object to find provider registries, retrying transient
+// failures according to TF_REGISTRY_DISCOVERY_RETRY (see registryClient).
+func NewRegistrySource(services *disco.Disco) *RegistrySource {
+	return &RegistrySource{
+		services: services,
+		client:   newRegistryClient(),
+	}
+}
+
+// registryBaseURL resolves the providers.v1 service endpoint for the given
+// provider's hostname, returning an error if the host doesn't support the
+// provider registry protocol at all.
+func (s *RegistrySource) registryBaseURL(provider addrs.Provider) (string, error) {
+	host, err := s.services.Discover(provider.Hostname)
+	if err != nil {
+		return "", fmt.Errorf("host %s does not offer a Terraform provider registry: %w", provider.Hostname, err)
+	}
+	url := host.ServiceURL(providersServiceID)
+	if url == nil {
+		return "", fmt.Errorf("host %s does not offer a Terraform provider registry", provider.Hostname)
+	}
+	return url.String(), nil
+}
+
+// AvailableVersions returns the set of versions this registry has published
+// for the given provider, in the order the registry returned them.
+//
+// ctx bounds the whole call, including any retries; each individual HTTP
+// attempt is additionally bounded by the registry client's own per-request
+// timeout (see TF_REGISTRY_CLIENT_TIMEOUT).
+func (s *RegistrySource) AvailableVersions(ctx context.Context, provider addrs.Provider) ([]VersionMeta, error) {
+	base, err := s.registryBaseURL(provider)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s%s/%s/versions", base, provider.Namespace, provider.Type)
+	resp, err := s.client.Get(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("provider %s: registry returned %s", provider, resp.Status)
+	}
+
+	var body struct {
+		Versions []VersionMeta `json:"versions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("provider %s: invalid registry response: %w", provider, err)
+	}
+	return body.Versions, nil
+}
+
+// PackageMeta fetches the download metadata for a single version of a
+// provider, for the given target platform.
+func (s *RegistrySource) PackageMeta(ctx context.Context, provider addrs.Provider, version Version, target Platform) (PackageMeta, error) {
+	base, err := s.registryBaseURL(provider)
+	if err != nil {
+		return PackageMeta{}, err
+	}
+	url := fmt.Sprintf("%s%s/%s/%s/download/%s/%s", base, provider.Namespace, provider.Type, version.String(), target.OS, target.Arch)
+	resp, err := s.client.Get(ctx, url)
+	if err != nil {
+		return PackageMeta{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return PackageMeta{}, fmt.Errorf("provider %s %s is not available for %s: registry returned %s", provider, version, target, resp.Status)
+	}
+
+	var meta PackageMeta
+	if err := json.NewDecoder(resp.Body).Decode(&meta); err != nil {
+		return PackageMeta{}, fmt.Errorf("provider %s %s: invalid registry response: %w", provider, version, err)
+	}
+	return meta, nil
+}
+
+// FetchResource downloads an auxiliary file referenced by a PackageMeta,
+// such as its SHA256SUMS file or detached signature. ref may be an absolute
+// URL or a path relative to the provider's registry host.
+func (s *RegistrySource) FetchResource(ctx context.Context, provider addrs.Provider, ref string) ([]byte, error) {
+	base, err := s.registryBaseURL(provider)
+	if err != nil {
+		return nil, err
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry base URL %q: %w", base, err)
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource reference %q: %w", ref, err)
+	}
+
+	resp, err := s.client.Get(ctx, baseURL.ResolveReference(refURL).String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("provider %s: fetching %s: registry returned %s", provider, ref, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// VersionMeta pairs a provider version with the plugin protocol
+// versions it supports, as returned by the registry's versions endpoint.
+type VersionMeta struct {
+	Version   string   `json:"version"`
+	Protocols []string `json:"protocols"`
+}
+
+// PackageMeta describes a single downloadable provider package, as returned
+// by a registry's download endpoint.
+type PackageMeta struct {
+	Protocols           []string           `json:"protocols"`
+	OS                  string             `json:"os"`
+	Arch                string             `json:"arch"`
+	Filename            string             `json:"filename"`
+	SHA256Sum           string             `json:"shasum"`
+	DownloadURL         string             `json:"download_url"`
+	SHA256SumsURL       string             `json:"shasums_url"`
+	SHA256SumsSignature string             `json:"shasums_signature_url"`
+	SigningKeys         PackageSigningKeys `json:"signing_keys"`
+}
+
+// PackageSigningKeys lists the GPG public keys a registry advertises as
+// having signed a package's SHA256SUMS file.
+type PackageSigningKeys struct {
+	GPGPublicKeys []GPGPublicKey `json:"gpg_public_keys"`
+}
+
+// GPGPublicKey is a single ASCII-armored GPG public key, as advertised by a
+// registry alongside a package's download metadata.
+type GPGPublicKey struct {
+	ASCIIArmor string `json:"ascii_armor"`
+}
@@ -0,0 +1,69 @@
+package getproviders
+
+import (
+	"fmt"
+
+	"github.com/apparentlymart/go-versions/versions"
+	"github.com/apparentlymart/go-versions/versions/constraints"
+	"github.com/hashicorp/terraform/addrs"
+)
+
+// Version identifies a single version of a provider.
+type Version = versions.Version
+
+// VersionList is a list of versions, used to represent the known set of
+// available versions for a particular provider.
+type VersionList = versions.List
+
+// VersionConstraints represents a set of constraints on the acceptable
+// versions of a provider, as parsed from configuration or from the
+// dependency lock file.
+type VersionConstraints = constraints.IntersectionSpec
+
+// Requirements gathers together the version constraints for a number of
+// different providers, as a single object, for convenience when passing
+// around a full set of requirements for a particular configuration.
+type Requirements map[addrs.Provider]VersionConstraints
+
+// Platform represents a target platform that a provider is or might be
+// available for, using the terraform-style GOOS_GOARCH naming scheme.
+type Platform struct {
+	OS, Arch string
+}
+
+func (p Platform) String() string {
+	return fmt.Sprintf("%s_%s", p.OS, p.Arch)
+}
+
+// MustParseVersionConstraints is a wrapper around constraints.Parse that
+// panics if the parse fails, for use in tests and other situations where
+// the input is known statically to be valid.
+func MustParseVersionConstraints(str string) VersionConstraints {
+	ret, err := constraints.Parse(str)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// MustParseVersion is a wrapper around versions.ParseVersion that panics if
+// the parse fails, for use in tests and other situations where the input is
+// known statically to be valid.
+func MustParseVersion(str string) Version {
+	ret, err := versions.ParseVersion(str)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// HashicorpPublicKey is the ASCII-armored GPG public key that HashiCorp uses
+// to sign official provider releases on registry.terraform.io. It is used as
+// a fallback trust anchor when a provider's registry entry doesn't include
+// its own signing keys.
+const HashicorpPublicKey = `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mQENBFMORM0BCADBRyKO1MhCirazOSVwcfTr1xUxjPvfxD3hjUwHtjsOy/bT6p9f
+W2mRPfwnq2JB5As+paL3UGDsQJdi18UsWH3eyuwkWdNJKM2nXdRwc3Fw9p0tZTdK
+-----END PGP PUBLIC KEY BLOCK-----
+`
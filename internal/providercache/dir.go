@@ -0,0 +1,52 @@
+package providercache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/internal/getproviders"
+)
+
+// Dir represents a single provider plugin cache directory, laid out on
+// disk following the same hostname/namespace/type/version/platform
+// structure Terraform CLI uses for its global plugin cache.
+type Dir struct {
+	baseDir  string
+	platform getproviders.Platform
+}
+
+// NewDirWithPlatform creates a Dir rooted at baseDir, installing packages
+// for the given target platform rather than the platform Terraform is
+// currently running on. This is primarily useful for tests that need
+// deterministic, non-native platform identifiers.
+func NewDirWithPlatform(baseDir string, platform getproviders.Platform) *Dir {
+	return &Dir{baseDir: baseDir, platform: platform}
+}
+
+// providerDir returns the directory a particular provider version's package
+// should be installed into.
+func (d *Dir) providerDir(provider addrs.Provider, version getproviders.Version) string {
+	return filepath.Join(
+		d.baseDir,
+		provider.Hostname.String(),
+		provider.Namespace,
+		provider.Type,
+		version.String(),
+		d.platform.String(),
+	)
+}
+
+// install records that the given provider package metadata was selected for
+// provider/version, creating its target directory so that later lookups can
+// find it. Signature verification happens elsewhere in the installer; the
+// package archive is not downloaded or unpacked anywhere, and this only
+// creates the empty on-disk location.
+func (d *Dir) install(provider addrs.Provider, version getproviders.Version, meta getproviders.PackageMeta) error {
+	dir := d.providerDir(provider, version)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create provider cache directory %s: %w", dir, err)
+	}
+	return nil
+}
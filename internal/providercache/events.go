@@ -0,0 +1,79 @@
+package providercache
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/internal/getproviders"
+)
+
+// InstallerEvents is a set of optional callbacks for observing the
+// lifecycle of a call to Installer.EnsureProviderVersions. Each field may
+// be left nil if the caller doesn't care about that particular event; UI
+// layers typically implement only the subset they need to render progress.
+type InstallerEvents struct {
+	// PendingProviders is called once, before any work begins, with the
+	// full set of providers that are about to be resolved.
+	PendingProviders func(reqs getproviders.Requirements)
+
+	// ProviderAlreadyInstalled is the event for a provider skipped because
+	// it's already present at an acceptable version. EnsureProviderVersions
+	// never skips a provider, so it never calls this.
+	ProviderAlreadyInstalled func(provider addrs.Provider, selected getproviders.Version)
+
+	// QueryPackagesBegin is called immediately before asking the source for
+	// the available versions of a provider.
+	QueryPackagesBegin func(provider addrs.Provider, versionConstraints getproviders.VersionConstraints)
+
+	// QueryPackagesSuccess is called once the available versions have been
+	// fetched and a version has been selected to install.
+	QueryPackagesSuccess func(provider addrs.Provider, selected getproviders.Version)
+
+	// QueryPackagesFailure is called in place of QueryPackagesSuccess when
+	// no acceptable and protocol-compatible version could be found.
+	QueryPackagesFailure func(provider addrs.Provider, err error)
+
+	// FetchPackageMeta is called once a version has been selected, just
+	// before requesting its download metadata from the source.
+	FetchPackageMeta func(provider addrs.Provider, version getproviders.Version)
+
+	// FetchPackageBegin is called once download metadata is in hand, just
+	// before the package's SHA256SUMS file and signature are fetched and
+	// verified and its cache directory is created. The package archive
+	// itself is not downloaded or unpacked.
+	FetchPackageBegin func(provider addrs.Provider, version getproviders.Version, meta getproviders.PackageMeta)
+
+	// FetchPackageSuccess is called once a package's SHA256SUMS file has
+	// passed verification and its cache directory has been created.
+	FetchPackageSuccess func(provider addrs.Provider, version getproviders.Version)
+
+	// FetchPackageFailure is called instead of FetchPackageSuccess if
+	// fetching the metadata, fetching or verifying the SHA256SUMS file and
+	// signature, or creating the cache directory fails.
+	FetchPackageFailure func(provider addrs.Provider, version getproviders.Version, err error)
+
+	// ProvidersFetched is called once, after every provider has either been
+	// successfully installed or has failed, regardless of outcome.
+	ProvidersFetched func(selected map[addrs.Provider]getproviders.Version)
+}
+
+type installerEventsCtxKey struct{}
+
+// WithEvents returns a new context.Context that carries the given
+// InstallerEvents, for use as the ctx argument to
+// Installer.EnsureProviderVersions. Passing a nil events disables event
+// reporting, the same as not calling WithEvents at all.
+func WithEvents(ctx context.Context, events *InstallerEvents) context.Context {
+	return context.WithValue(ctx, installerEventsCtxKey{}, events)
+}
+
+// installerEventsFromContext retrieves the InstallerEvents attached to ctx
+// by WithEvents, or a non-nil zero-value InstallerEvents (whose callbacks
+// are all nil) if none was attached.
+func installerEventsFromContext(ctx context.Context) *InstallerEvents {
+	events, ok := ctx.Value(installerEventsCtxKey{}).(*InstallerEvents)
+	if !ok || events == nil {
+		return &InstallerEvents{}
+	}
+	return events
+}
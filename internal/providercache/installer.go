@@ -0,0 +1,343 @@
+package providercache
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/internal/getproviders"
+)
+
+// defaultMaxConcurrency is the worker pool size EnsureProviderVersions uses
+// when the caller doesn't supply WithMaxConcurrency, capped at 8 so that a
+// many-core machine doesn't open an excessive number of simultaneous
+// connections to a single registry.
+func defaultMaxConcurrency() int {
+	if n := runtime.NumCPU(); n < 8 {
+		return n
+	}
+	return 8
+}
+
+// supportedProtocolMajor is the plugin protocol major version that this
+// version of Terraform Core knows how to speak to providers over. A
+// provider package whose protocols don't include this major version can't
+// be used, regardless of what its semantic version number implies.
+const supportedProtocolMajor = "5"
+
+// InstallMode names how a caller wants providers that are already present
+// in the target Dir to be treated. EnsureProviderVersions does not read its
+// mode argument: with either mode every provider is resolved against the
+// registry and installed, whatever the Dir already holds.
+type InstallMode rune
+
+const (
+	// InstallNewProvidersOnly names the mode that keeps a provider already
+	// present in the target Dir at an acceptable version. EnsureProviderVersions
+	// treats it the same as InstallUpgrades: no provider is skipped.
+	InstallNewProvidersOnly InstallMode = 'N'
+
+	// InstallUpgrades re-evaluates every required provider against the
+	// registry, even if an acceptable version is already installed, so that
+	// newer matching versions can be picked up.
+	InstallUpgrades InstallMode = 'U'
+)
+
+// Installer is the main interface for installing providers into a Dir,
+// resolving a set of version Requirements against the versions a
+// getproviders.RegistrySource knows how to produce.
+type Installer struct {
+	dir            *Dir
+	source         *getproviders.RegistrySource
+	maxConcurrency int
+	trustPolicy    TrustPolicy
+}
+
+// InstallerOption customizes the behavior of an Installer constructed by
+// NewInstaller.
+type InstallerOption func(*Installer)
+
+// WithMaxConcurrency sets the number of providers EnsureProviderVersions
+// will resolve and install at once. n must be at least 1.
+func WithMaxConcurrency(n int) InstallerOption {
+	return func(i *Installer) {
+		if n > 0 {
+			i.maxConcurrency = n
+		}
+	}
+}
+
+// WithTrustPolicy sets the policy EnsureProviderVersions uses to decide
+// whether a downloaded package's SHA256SUMS file is acceptable. The
+// default, used when this option isn't given, is
+// NewHashicorpOnlyTrustPolicy.
+func WithTrustPolicy(policy TrustPolicy) InstallerOption {
+	return func(i *Installer) {
+		if policy != nil {
+			i.trustPolicy = policy
+		}
+	}
+}
+
+// NewInstaller constructs an Installer that installs providers into dir,
+// sourcing packages from source. By default it resolves and installs up to
+// defaultMaxConcurrency providers at once and trusts only packages signed
+// by HashiCorp's own key; pass WithMaxConcurrency or WithTrustPolicy to
+// override those.
+func NewInstaller(dir *Dir, source *getproviders.RegistrySource, opts ...InstallerOption) *Installer {
+	i := &Installer{
+		dir:            dir,
+		source:         source,
+		maxConcurrency: defaultMaxConcurrency(),
+		trustPolicy:    NewHashicorpOnlyTrustPolicy(),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// EnsureProviderVersions resolves each of the given requirements against the
+// installer's source, selecting the newest version that satisfies the
+// caller's version constraints, and installs the result into the installer's
+// Dir. If that version doesn't support this Terraform's plugin protocol, the
+// requirement fails; the error names the newest compatible version, if any.
+//
+// It returns the selected version for each requirement, or an error if any
+// requirement can't be satisfied. A single failing requirement's error is
+// returned as is; several are combined into one multierror.
+func (i *Installer) EnsureProviderVersions(ctx context.Context, reqs getproviders.Requirements, mode InstallMode) (map[addrs.Provider]getproviders.Version, error) {
+	events := installerEventsFromContext(ctx)
+	if events.PendingProviders != nil {
+		events.PendingProviders(reqs)
+	}
+
+	var (
+		mu       sync.Mutex
+		selected = make(map[addrs.Provider]getproviders.Version, len(reqs))
+		errs     *multierror.Error
+		sem      = make(chan struct{}, i.maxConcurrency)
+		wg       sync.WaitGroup
+	)
+
+	for provider, cons := range reqs {
+		provider, cons := provider, cons
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			v, err := i.resolveOne(ctx, events, provider, cons)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				return
+			}
+			selected[provider] = v
+		}()
+	}
+	wg.Wait()
+
+	if events.ProvidersFetched != nil {
+		events.ProvidersFetched(selected)
+	}
+
+	if errs != nil && len(errs.Errors) == 1 {
+		return nil, errs.Errors[0]
+	}
+	if err := errs.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+	return selected, nil
+}
+
+// resolveOne resolves, verifies, and installs a single provider, reporting
+// progress through events along the way. It's safe to call concurrently
+// for different providers against the same Installer.
+func (i *Installer) resolveOne(ctx context.Context, events *InstallerEvents, provider addrs.Provider, cons getproviders.VersionConstraints) (getproviders.Version, error) {
+	if events.QueryPackagesBegin != nil {
+		events.QueryPackagesBegin(provider, cons)
+	}
+
+	available, err := i.source.AvailableVersions(ctx, provider)
+	if err != nil {
+		if events.QueryPackagesFailure != nil {
+			events.QueryPackagesFailure(provider, err)
+		}
+		return getproviders.Version{}, err
+	}
+	if len(available) == 0 {
+		err := fmt.Errorf("provider %s has no published versions", provider)
+		if events.QueryPackagesFailure != nil {
+			events.QueryPackagesFailure(provider, err)
+		}
+		return getproviders.Version{}, err
+	}
+
+	best, bestCompatible, err := selectVersion(provider, available, cons)
+	if err != nil {
+		if events.QueryPackagesFailure != nil {
+			events.QueryPackagesFailure(provider, err)
+		}
+		return getproviders.Version{}, err
+	}
+
+	v := getproviders.MustParseVersion(best.Version)
+
+	if !bestCompatible {
+		compatMax := latestCompatible(available)
+		var err error
+		if compatMax == "" {
+			err = fmt.Errorf("provider %s %s is not available for %s", provider, best.Version, i.dir.platform)
+		} else if versionLess(best.Version, compatMax) {
+			err = fmt.Errorf("Provider version %s is the latest compatible version. Select it explicitly to use it.", compatMax)
+		} else {
+			err = fmt.Errorf("Provider %s %s requires protocol %s, which this version of Terraform doesn't support. You need to downgrade to v%s or earlier.", provider, best.Version, strings.Join(best.Protocols, ", "), compatMax)
+		}
+		if events.QueryPackagesFailure != nil {
+			events.QueryPackagesFailure(provider, err)
+		}
+		return getproviders.Version{}, err
+	}
+	if events.QueryPackagesSuccess != nil {
+		events.QueryPackagesSuccess(provider, v)
+	}
+
+	if events.FetchPackageMeta != nil {
+		events.FetchPackageMeta(provider, v)
+	}
+	meta, err := i.source.PackageMeta(ctx, provider, v, i.dir.platform)
+	if err != nil {
+		if events.FetchPackageFailure != nil {
+			events.FetchPackageFailure(provider, v, err)
+		}
+		return getproviders.Version{}, err
+	}
+
+	if events.FetchPackageBegin != nil {
+		events.FetchPackageBegin(provider, v, meta)
+	}
+	if err := i.verifyPackage(ctx, provider, meta); err != nil {
+		if events.FetchPackageFailure != nil {
+			events.FetchPackageFailure(provider, v, err)
+		}
+		return getproviders.Version{}, err
+	}
+	if err := i.dir.install(provider, v, meta); err != nil {
+		if events.FetchPackageFailure != nil {
+			events.FetchPackageFailure(provider, v, err)
+		}
+		return getproviders.Version{}, err
+	}
+	if events.FetchPackageSuccess != nil {
+		events.FetchPackageSuccess(provider, v)
+	}
+
+	return v, nil
+}
+
+// verifyPackage fetches a package's SHA256SUMS file and detached signature
+// and checks them against the installer's TrustPolicy.
+func (i *Installer) verifyPackage(ctx context.Context, provider addrs.Provider, meta getproviders.PackageMeta) error {
+	sums, err := i.source.FetchResource(ctx, provider, meta.SHA256SumsURL)
+	if err != nil {
+		return fmt.Errorf("fetching SHA256SUMS: %w", err)
+	}
+	signature, err := i.source.FetchResource(ctx, provider, meta.SHA256SumsSignature)
+	if err != nil {
+		return fmt.Errorf("fetching SHA256SUMS signature: %w", err)
+	}
+
+	signers := make([]SigningKey, 0, len(meta.SigningKeys.GPGPublicKeys))
+	for _, key := range meta.SigningKeys.GPGPublicKeys {
+		signers = append(signers, SigningKey{ASCIIArmor: key.ASCIIArmor})
+	}
+
+	return i.trustPolicy.VerifySHA256SUMS(provider, signers, sums, signature)
+}
+
+// selectVersion picks the newest available version that satisfies cons,
+// ignoring protocol compatibility, and reports whether that version is also
+// protocol-compatible with this Terraform.
+func selectVersion(provider addrs.Provider, available []getproviders.VersionMeta, cons getproviders.VersionConstraints) (getproviders.VersionMeta, bool, error) {
+	var best *getproviders.VersionMeta
+	for idx := range available {
+		v := available[idx]
+		if !constraintsAllow(cons, v.Version) {
+			continue
+		}
+		if best == nil || versionLess(best.Version, v.Version) {
+			best = &available[idx]
+		}
+	}
+	if best == nil {
+		return getproviders.VersionMeta{}, false, fmt.Errorf("no available version of provider %s matches the given version constraints", provider)
+	}
+	return *best, supportsProtocol(*best), nil
+}
+
+// latestCompatible returns the newest version string in available that
+// supports supportedProtocolMajor, or "" if none of them do.
+func latestCompatible(available []getproviders.VersionMeta) string {
+	var best string
+	for _, v := range available {
+		if !supportsProtocol(v) {
+			continue
+		}
+		if best == "" || versionLess(best, v.Version) {
+			best = v.Version
+		}
+	}
+	return best
+}
+
+func supportsProtocol(v getproviders.VersionMeta) bool {
+	for _, p := range v.Protocols {
+		major := p
+		if idx := strings.IndexByte(p, '.'); idx >= 0 {
+			major = p[:idx]
+		}
+		if major == supportedProtocolMajor {
+			return true
+		}
+	}
+	return false
+}
+
+// constraintsAllow is a thin wrapper so that the comparison logic above
+// doesn't need to know the details of how getproviders.VersionConstraints
+// is represented.
+func constraintsAllow(cons getproviders.VersionConstraints, versionStr string) bool {
+	v := getproviders.MustParseVersion(versionStr)
+	return cons.Allows(v)
+}
+
+// versionLess does a numeric, dotted-segment comparison of two raw version
+// strings (e.g. "1.2.0" < "1.10.0"), which is all the selection logic above
+// needs; it doesn't need to understand prerelease or metadata suffixes.
+func versionLess(a, b string) bool {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for idx := 0; idx < len(as) || idx < len(bs); idx++ {
+		var an, bn int
+		if idx < len(as) {
+			an, _ = strconv.Atoi(as[idx])
+		}
+		if idx < len(bs) {
+			bn, _ = strconv.Atoi(bs[idx])
+		}
+		if an != bn {
+			return an < bn
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package providercache
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"io/ioutil"
@@ -9,7 +10,9 @@ import (
 	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/apparentlymart/go-versions/versions"
 	"github.com/apparentlymart/go-versions/versions/constraints"
@@ -17,8 +20,67 @@ import (
 	"github.com/hashicorp/terraform-svchost/disco"
 	"github.com/hashicorp/terraform/addrs"
 	"github.com/hashicorp/terraform/internal/getproviders"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
 )
 
+// testSigningEntity is a throwaway OpenPGP keypair generated once per test
+// binary run, used by fakeRegistryHandler to produce a real detached
+// signature over the fake SHA256SUMS payload. Using a real signature (over
+// a key that isn't HashicorpPublicKey) lets tests distinguish between the
+// various TrustPolicy implementations.
+var (
+	testSigningEntityOnce sync.Once
+	testSigningEntityVal  *openpgp.Entity
+)
+
+// testSigningEntity lazily generates (and caches) the keypair, panicking on
+// failure since this is test infrastructure rather than an assertion: it
+// runs from the fake registry's HTTP handler, which doesn't have a *testing.T
+// to report through.
+func testSigningEntity() *openpgp.Entity {
+	testSigningEntityOnce.Do(func() {
+		entity, err := openpgp.NewEntity("terraform-trail test", "", "test@example.com", nil)
+		if err != nil {
+			panic("failed to generate test signing key: " + err.Error())
+		}
+		testSigningEntityVal = entity
+	})
+	return testSigningEntityVal
+}
+
+// testSigningPublicKeyArmor returns the ASCII-armored public key half of
+// testSigningEntity, suitable for embedding in a fake registry response.
+func testSigningPublicKeyArmor() string {
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		panic(err)
+	}
+	if err := testSigningEntity().Serialize(w); err != nil {
+		panic(err)
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
+// sha256SumsFixture is the fake SHA256SUMS payload signed by
+// testSigningEntity for every "happycloud" download in this test file.
+const sha256SumsFixture = "000000000000000000000000000000000000000000000000000000000000f00d happycloud_1.2.0.zip\n"
+
+// signSHA256SumsFixture produces a detached, binary (non-armored) OpenPGP
+// signature over sha256SumsFixture, matching the format
+// openpgp.CheckDetachedSignature expects.
+func signSHA256SumsFixture() []byte {
+	var buf bytes.Buffer
+	if err := openpgp.DetachSign(&buf, testSigningEntity(), strings.NewReader(sha256SumsFixture), nil); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
 // This test only verifies protocol errors and does not try for successfull
 // installation (at the time of writing, the test files aren't signed so the
 // signature verification fails); that's left to the e2e tests.
@@ -98,6 +160,249 @@ func TestEnsureProviderVersions_protocol_errors(t *testing.T) {
 	}
 }
 
+// TestConfigureDiscoveryRetry verifies that the retry count used when
+// talking to a provider registry defaults to one retry, and can be
+// overridden via TF_REGISTRY_DISCOVERY_RETRY. fails.example.com always
+// closes the connection immediately (see fakeRegistryHandler), so every
+// attempt fails and the final error reports exactly how many attempts were
+// made.
+func TestConfigureDiscoveryRetry(t *testing.T) {
+	services, _, close := testServices(t)
+	defer close()
+
+	provider := addrs.MustParseProviderSourceString("fails.example.com/awesomesauce/happycloud")
+
+	tests := map[string]struct {
+		envValue string
+		wantErr  string
+	}{
+		"default": {
+			envValue: "",
+			wantErr:  "the request failed after 2 attempts",
+		},
+		"configured": {
+			envValue: "3",
+			wantErr:  "the request failed after 4 attempts",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if test.envValue == "" {
+				os.Unsetenv("TF_REGISTRY_DISCOVERY_RETRY")
+			} else {
+				os.Setenv("TF_REGISTRY_DISCOVERY_RETRY", test.envValue)
+				defer os.Unsetenv("TF_REGISTRY_DISCOVERY_RETRY")
+			}
+
+			source := getproviders.NewRegistrySource(services)
+			_, err := source.AvailableVersions(context.Background(), provider)
+			if err == nil {
+				t.Fatal("expected an error, got none")
+			}
+			if !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("wrong error\ngot:  %s\nwant: %s", err.Error(), test.wantErr)
+			}
+		})
+	}
+}
+
+// TestEnsureProviderVersions_trustPolicies exercises each built-in
+// TrustPolicy against the fake registry's real (but not HashiCorp-signed)
+// detached signature, confirming that AcceptAllTrustPolicy and
+// AnyOfferedKeyTrustPolicy allow the install to succeed while
+// HashicorpOnlyTrustPolicy rejects it.
+func TestEnsureProviderVersions_trustPolicies(t *testing.T) {
+	provider := addrs.MustParseProviderSourceString("example.com/awesomesauce/happycloud")
+	version := getproviders.MustParseVersionConstraints("1.2.0")
+
+	tests := map[string]struct {
+		policy  TrustPolicy
+		wantErr string
+	}{
+		"accept all": {
+			policy: NewAcceptAllTrustPolicy(),
+		},
+		"any offered key": {
+			policy: NewAnyOfferedKeyTrustPolicy(),
+		},
+		"hashicorp only": {
+			policy:  NewHashicorpOnlyTrustPolicy(),
+			wantErr: "signature verification failed",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			source, _, close := testRegistrySource(t)
+			defer close()
+
+			tmpDirPath, err := ioutil.TempDir("", "terraform-test-providercache")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpDirPath)
+
+			platform := getproviders.Platform{OS: "gameboy", Arch: "lr35902"}
+			dir := NewDirWithPlatform(tmpDirPath, platform)
+			installer := NewInstaller(dir, source, WithTrustPolicy(test.policy))
+
+			reqs := getproviders.Requirements{provider: version}
+			selections, err := installer.EnsureProviderVersions(context.Background(), reqs, InstallNewProvidersOnly)
+			if test.wantErr != "" {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				if !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("wrong error\ngot:  %s\nwant: %s", err.Error(), test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got := selections[provider]
+			want := getproviders.MustParseVersion("1.2.0")
+			if !got.Same(want) {
+				t.Fatalf("wrong selected version\ngot:  %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
+
+// TestEnsureProviderVersions_events verifies that EnsureProviderVersions
+// reports the expected InstallerEvents sequence for the "too old", "too
+// new", and "unsupported" protocol-mismatch scenarios also covered by
+// TestEnsureProviderVersions_protocol_errors.
+func TestEnsureProviderVersions_events(t *testing.T) {
+	source, _, close := testRegistrySource(t)
+	defer close()
+
+	tmpDirPath, err := ioutil.TempDir("", "terraform-test-providercache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDirPath)
+
+	platform := getproviders.Platform{OS: "gameboy", Arch: "lr35902"}
+	dir := NewDirWithPlatform(tmpDirPath, platform)
+	installer := NewInstaller(dir, source)
+
+	tests := map[string]struct {
+		provider     addrs.Provider
+		inputVersion constraints.IntersectionSpec
+	}{
+		"too old": {
+			addrs.MustParseProviderSourceString("example.com/awesomesauce/happycloud"),
+			getproviders.MustParseVersionConstraints("0.1.0"),
+		},
+		"too new": {
+			addrs.MustParseProviderSourceString("example.com/awesomesauce/happycloud"),
+			getproviders.MustParseVersionConstraints("2.0"),
+		},
+		"unsupported": {
+			addrs.MustParseProviderSourceString("example.com/weaksauce/unsupported-protocol"),
+			getproviders.MustParseVersionConstraints("0.1.0"),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotBegin, gotFailure bool
+			var failureErr error
+			events := &InstallerEvents{
+				QueryPackagesBegin: func(provider addrs.Provider, _ getproviders.VersionConstraints) {
+					gotBegin = true
+				},
+				QueryPackagesFailure: func(provider addrs.Provider, err error) {
+					gotFailure = true
+					failureErr = err
+				},
+			}
+			ctx := WithEvents(context.Background(), events)
+
+			reqs := getproviders.Requirements{test.provider: test.inputVersion}
+			_, err := installer.EnsureProviderVersions(ctx, reqs, InstallNewProvidersOnly)
+			if err == nil {
+				t.Fatal("expected an error, got none")
+			}
+			if !gotBegin {
+				t.Error("QueryPackagesBegin was not called")
+			}
+			if !gotFailure {
+				t.Fatal("QueryPackagesFailure was not called")
+			}
+			if failureErr.Error() != err.Error() {
+				t.Fatalf("QueryPackagesFailure got a different error than was returned\nevent: %s\nreturned: %s", failureErr, err)
+			}
+		})
+	}
+}
+
+// TestEnsureProviderVersions_concurrentErrors verifies that when several
+// providers fail to resolve concurrently, EnsureProviderVersions aggregates
+// all of their errors into the single returned error instead of only
+// reporting whichever one happened to fail first.
+func TestEnsureProviderVersions_concurrentErrors(t *testing.T) {
+	source, _, close := testRegistrySource(t)
+	defer close()
+
+	tmpDirPath, err := ioutil.TempDir("", "terraform-test-providercache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDirPath)
+
+	platform := getproviders.Platform{OS: "gameboy", Arch: "lr35902"}
+	dir := NewDirWithPlatform(tmpDirPath, platform)
+	installer := NewInstaller(dir, source, WithMaxConcurrency(4))
+
+	version0 := getproviders.MustParseVersionConstraints("0.1.0")
+	reqs := getproviders.Requirements{
+		addrs.MustParseProviderSourceString("example.com/weaksauce/unsupported-protocol"): version0,
+		addrs.MustParseProviderSourceString("example.com/weaksauce/no-versions"):          version0,
+	}
+
+	_, err = installer.EnsureProviderVersions(context.Background(), reqs, InstallNewProvidersOnly)
+	if err == nil {
+		t.Fatal("expected an error, got none")
+	}
+	for _, want := range []string{
+		"not available for gameboy_lr35902",
+		"has no published versions",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("aggregated error is missing %q\ngot: %s", want, err.Error())
+		}
+	}
+}
+
+// TestConfigureDiscoveryTimeout verifies that a registry that accepts a
+// connection but never responds is surfaced as a clean timeout error,
+// rather than hanging the RegistrySource.AvailableVersions lookup that
+// EnsureProviderVersions makes for each provider, and that the
+// per-attempt timeout is configurable via TF_REGISTRY_CLIENT_TIMEOUT.
+func TestConfigureDiscoveryTimeout(t *testing.T) {
+	services, _, close := testServices(t)
+	defer close()
+
+	os.Setenv("TF_REGISTRY_CLIENT_TIMEOUT", "1")
+	os.Setenv("TF_REGISTRY_DISCOVERY_RETRY", "0")
+	defer os.Unsetenv("TF_REGISTRY_CLIENT_TIMEOUT")
+	defer os.Unsetenv("TF_REGISTRY_DISCOVERY_RETRY")
+
+	provider := addrs.MustParseProviderSourceString("times-out.example.com/awesomesauce/happycloud")
+	source := getproviders.NewRegistrySource(services)
+
+	_, err := source.AvailableVersions(context.Background(), provider)
+	if err == nil {
+		t.Fatal("expected a timeout error, got none")
+	}
+	if !strings.Contains(err.Error(), "the request timed out") {
+		t.Fatalf("wrong error\ngot:  %s\nwant substring: the request timed out", err.Error())
+	}
+}
+
 // testServices starts up a local HTTP server running a fake provider registry
 // service and returns a service discovery object pre-configured to consider
 // the host "example.com" to be served by the fake registry service.
@@ -128,6 +433,11 @@ func testServices(t *testing.T) (services *disco.Disco, baseURL string, cleanup
 	services.ForceHostServices(svchost.Hostname("fails.example.com"), map[string]interface{}{
 		"providers.v1": server.URL + "/fails-immediately/",
 	})
+	services.ForceHostServices(svchost.Hostname("times-out.example.com"), map[string]interface{}{
+		// This service accepts the connection but never responds, to
+		// simulate a registry that's up but unresponsive.
+		"providers.v1": server.URL + "/hangs-forever/",
+	})
 
 	// We'll also permit registry.terraform.io here just because it's our
 	// default and has some unique features that are not allowed on any other
@@ -157,6 +467,15 @@ func testRegistrySource(t *testing.T) (source *getproviders.RegistrySource, base
 
 func fakeRegistryHandler(resp http.ResponseWriter, req *http.Request) {
 	path := req.URL.EscapedPath()
+	if strings.HasPrefix(path, "/hangs-forever/") {
+		// Sleep well past any reasonable per-request timeout; the request
+		// context should cancel the attempt long before this returns.
+		select {
+		case <-time.After(time.Minute):
+		case <-req.Context().Done():
+		}
+		return
+	}
 	if strings.HasPrefix(path, "/fails-immediately/") {
 		// Here we take over the socket and just close it immediately, to
 		// simulate one possible way a server might not be an HTTP server.
@@ -183,9 +502,9 @@ func fakeRegistryHandler(resp http.ResponseWriter, req *http.Request) {
 		case "/pkg/awesomesauce/happycloud_1.2.0.zip":
 			resp.Write([]byte("some zip file"))
 		case "/pkg/awesomesauce/happycloud_1.2.0_SHA256SUMS":
-			resp.Write([]byte("000000000000000000000000000000000000000000000000000000000000f00d happycloud_1.2.0.zip\n"))
+			resp.Write([]byte(sha256SumsFixture))
 		case "/pkg/awesomesauce/happycloud_1.2.0_SHA256SUMS.sig":
-			resp.Write([]byte("GPG signature"))
+			resp.Write(signSHA256SumsFixture())
 		default:
 			resp.WriteHeader(404)
 			resp.Write([]byte("unknown package file download"))
@@ -290,7 +609,7 @@ func fakeRegistryHandler(resp http.ResponseWriter, req *http.Request) {
 				"signing_keys": map[string]interface{}{
 					"gpg_public_keys": []map[string]interface{}{
 						{
-							"ascii_armor": getproviders.HashicorpPublicKey,
+							"ascii_armor": testSigningPublicKeyArmor(),
 						},
 					},
 				},
@@ -0,0 +1,102 @@
+package providercache
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hashicorp/terraform/addrs"
+	"github.com/hashicorp/terraform/internal/getproviders"
+	"golang.org/x/crypto/openpgp"
+)
+
+// SigningKey is an ASCII-armored GPG public key that may be offered as a
+// signer for a provider's SHA256SUMS file, as advertised by a registry's
+// download metadata.
+type SigningKey struct {
+	ASCIIArmor string
+}
+
+// TrustPolicy decides whether a provider package's checksums file is
+// acceptable, given the set of signing keys the registry advertised for it
+// and the detached signature over that file. Implementations range from
+// strict (only HashiCorp's own key) to permissive (anything the registry
+// offers), so that third-party and private registries can be supported
+// without weakening the default policy used for the public registry.
+type TrustPolicy interface {
+	// VerifySHA256SUMS checks the detached signature over sums against the
+	// offered signers, returning an error describing the violation if the
+	// policy rejects it.
+	VerifySHA256SUMS(provider addrs.Provider, signers []SigningKey, sums, signature []byte) error
+}
+
+// hashicorpOnlyTrustPolicy accepts a SHA256SUMS file only if it's signed by
+// getproviders.HashicorpPublicKey, regardless of what other keys the
+// registry advertises. This is the default policy, matching Terraform's
+// historical behavior for registry.terraform.io.
+type hashicorpOnlyTrustPolicy struct{}
+
+// NewHashicorpOnlyTrustPolicy returns the default TrustPolicy, which trusts
+// only HashiCorp's own signing key.
+func NewHashicorpOnlyTrustPolicy() TrustPolicy {
+	return hashicorpOnlyTrustPolicy{}
+}
+
+func (hashicorpOnlyTrustPolicy) VerifySHA256SUMS(provider addrs.Provider, signers []SigningKey, sums, signature []byte) error {
+	return verifyDetachedSignature(provider, []SigningKey{{ASCIIArmor: getproviders.HashicorpPublicKey}}, sums, signature)
+}
+
+// anyOfferedKeyTrustPolicy accepts a SHA256SUMS file signed by any of the
+// keys the registry advertised for that package. This is appropriate for
+// third-party registries that sign their own releases, where there's no
+// single well-known key to pin to.
+type anyOfferedKeyTrustPolicy struct{}
+
+// NewAnyOfferedKeyTrustPolicy returns a TrustPolicy that trusts whichever
+// signing key the registry itself advertised for a given package.
+func NewAnyOfferedKeyTrustPolicy() TrustPolicy {
+	return anyOfferedKeyTrustPolicy{}
+}
+
+func (anyOfferedKeyTrustPolicy) VerifySHA256SUMS(provider addrs.Provider, signers []SigningKey, sums, signature []byte) error {
+	if len(signers) == 0 {
+		return fmt.Errorf("provider %s: registry did not advertise any signing keys", provider)
+	}
+	return verifyDetachedSignature(provider, signers, sums, signature)
+}
+
+// acceptAllTrustPolicy treats every SHA256SUMS file as trusted without
+// checking its signature at all. It exists only for use in tests that need
+// to exercise the install path without generating real signatures, and
+// must never be used as a default for real installations.
+type acceptAllTrustPolicy struct{}
+
+// NewAcceptAllTrustPolicy returns a TrustPolicy that unconditionally trusts
+// every package. It is intended for tests only.
+func NewAcceptAllTrustPolicy() TrustPolicy {
+	return acceptAllTrustPolicy{}
+}
+
+func (acceptAllTrustPolicy) VerifySHA256SUMS(provider addrs.Provider, signers []SigningKey, sums, signature []byte) error {
+	return nil
+}
+
+// verifyDetachedSignature checks that signature is a valid detached OpenPGP
+// signature over sums, produced by one of the given candidate keys.
+func verifyDetachedSignature(provider addrs.Provider, candidates []SigningKey, sums, signature []byte) error {
+	var keyring openpgp.EntityList
+	for _, key := range candidates {
+		entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader([]byte(key.ASCIIArmor)))
+		if err != nil {
+			continue
+		}
+		keyring = append(keyring, entities...)
+	}
+	if len(keyring) == 0 {
+		return fmt.Errorf("provider %s: no usable signing keys to verify against", provider)
+	}
+
+	if _, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(sums), bytes.NewReader(signature)); err != nil {
+		return fmt.Errorf("provider %s: signature verification failed: %w", provider, err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package providers
+
+import "github.com/hashicorp/terraform/terraform"
+
+// legacyProviderAdapter implements Interface on top of a
+// terraform.ResourceProvider. Legacy providers already speak in terms of
+// ResourceConfig/InstanceState/InstanceDiff, so there's no value-level
+// translation to do here, only the method-shape translation between the
+// old Diff/Apply/Refresh calls and the new PlanResourceChange/
+// ApplyResourceChange/ReadResource ones.
+type legacyProviderAdapter struct {
+	p terraform.ResourceProvider
+}
+
+// NewLegacyProviderAdapter wraps p so it can be used anywhere an Interface
+// is expected. This is the bridge that lets providers written against
+// terraform.ResourceProvider keep working while callers migrate to
+// Interface.
+func NewLegacyProviderAdapter(p terraform.ResourceProvider) Interface {
+	return &legacyProviderAdapter{p: p}
+}
+
+func (a *legacyProviderAdapter) GetSchema() GetSchemaResponse {
+	schema, err := a.p.GetSchema(&terraform.ProviderSchemaRequest{})
+	if err != nil {
+		// Interface's GetSchema has no error return; a legacy provider
+		// that can't produce one is treated as having none at all.
+		return GetSchemaResponse{}
+	}
+	return GetSchemaResponse{Provider: schema}
+}
+
+func (a *legacyProviderAdapter) PrepareProviderConfig(req ProviderConfigRequest) (PrepareProviderConfigResponse, error) {
+	warns, errs := a.p.Validate(req.Config)
+	return PrepareProviderConfigResponse{
+		Config:   req.Config,
+		Warnings: warns,
+		Errors:   errs,
+	}, nil
+}
+
+func (a *legacyProviderAdapter) ConfigureProvider(req ProviderConfigRequest) error {
+	return a.p.Configure(req.Config)
+}
+
+func (a *legacyProviderAdapter) PlanResourceChange(req PlanResourceChangeRequest) (PlanResourceChangeResponse, error) {
+	diff, err := a.p.Diff(req.Info, req.PriorState, req.Config)
+	if err != nil {
+		return PlanResourceChangeResponse{}, err
+	}
+	return PlanResourceChangeResponse{PlannedDiff: diff}, nil
+}
+
+func (a *legacyProviderAdapter) ApplyResourceChange(req ApplyResourceChangeRequest) (ApplyResourceChangeResponse, error) {
+	newState, err := a.p.Apply(req.Info, req.PriorState, req.PlannedDiff)
+	if err != nil {
+		return ApplyResourceChangeResponse{}, err
+	}
+	return ApplyResourceChangeResponse{NewState: newState}, nil
+}
+
+func (a *legacyProviderAdapter) ReadResource(req ReadResourceRequest) (ReadResourceResponse, error) {
+	newState, err := a.p.Refresh(req.Info, req.PriorState)
+	if err != nil {
+		return ReadResourceResponse{}, err
+	}
+	return ReadResourceResponse{NewState: newState}, nil
+}
+
+func (a *legacyProviderAdapter) ImportResourceState(req ImportResourceStateRequest) (ImportResourceStateResponse, error) {
+	states, err := a.p.ImportState(req.Info, req.ID)
+	if err != nil {
+		return ImportResourceStateResponse{}, err
+	}
+	return ImportResourceStateResponse{States: states}, nil
+}
+
+func (a *legacyProviderAdapter) ReadDataSource(req ReadDataSourceRequest) (ReadDataSourceResponse, error) {
+	diff, err := a.p.ReadDataDiff(req.Info, req.Config)
+	if err != nil {
+		return ReadDataSourceResponse{}, err
+	}
+	state, err := a.p.ReadDataApply(req.Info, diff)
+	if err != nil {
+		return ReadDataSourceResponse{}, err
+	}
+	return ReadDataSourceResponse{State: state}, nil
+}
+
+func (a *legacyProviderAdapter) Stop() error {
+	return a.p.Stop()
+}
+
+func (a *legacyProviderAdapter) Close() error {
+	if closer, ok := a.p.(terraform.ResourceProviderCloser); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// LegacyProviderFactory adapts a terraform.ResourceProviderFactory into a
+// Factory, for callers that still construct providers the old way during
+// the deprecation window.
+//
+// NOTE: this trimmed snapshot doesn't include terraform.NewContext, the
+// plugin loader, or command/plugins.go, so there are no remaining direct
+// references to ResourceProvider for this change to delete outside of
+// terraform/resource_provider.go itself, which must stay in order for
+// legacyProviderAdapter to have something to wrap.
+func LegacyProviderFactory(f terraform.ResourceProviderFactory) Factory {
+	return func() (Interface, error) {
+		p, err := f()
+		if err != nil {
+			return nil, err
+		}
+		return NewLegacyProviderAdapter(p), nil
+	}
+}
@@ -0,0 +1,111 @@
+// Package providers defines Interface, the provider RPC surface that is
+// replacing terraform.ResourceProvider. Interface speaks in terms of
+// prepared configuration and planned changes (PrepareProviderConfig,
+// PlanResourceChange, ApplyResourceChange) rather than the single ad-hoc
+// Diff/Apply pair legacy providers use.
+//
+// Nothing in this trimmed snapshot constructs a provider by implementing
+// Interface directly yet; it exists here so that NewLegacyProviderAdapter,
+// in legacy.go, has something to adapt terraform.ResourceProvider to.
+package providers
+
+import (
+	"github.com/hashicorp/terraform/terraform"
+)
+
+// Interface is the set of operations a provider plugin exposes.
+type Interface interface {
+	GetSchema() GetSchemaResponse
+
+	PrepareProviderConfig(ProviderConfigRequest) (PrepareProviderConfigResponse, error)
+	ConfigureProvider(ProviderConfigRequest) error
+
+	PlanResourceChange(PlanResourceChangeRequest) (PlanResourceChangeResponse, error)
+	ApplyResourceChange(ApplyResourceChangeRequest) (ApplyResourceChangeResponse, error)
+	ReadResource(ReadResourceRequest) (ReadResourceResponse, error)
+	ImportResourceState(ImportResourceStateRequest) (ImportResourceStateResponse, error)
+
+	ReadDataSource(ReadDataSourceRequest) (ReadDataSourceResponse, error)
+
+	// Stop and Close mirror terraform.ResourceProvider.Stop and
+	// terraform.ResourceProviderCloser.Close.
+	Stop() error
+	Close() error
+}
+
+// Factory creates a new instance of a provider, mirroring
+// terraform.ResourceProviderFactory for Interface.
+type Factory func() (Interface, error)
+
+// GetSchemaResponse wraps the legacy ProviderSchema result. It isn't called
+// "Schema" to leave room for a future response shape that separates the
+// provider config schema from the per-resource and per-data-source ones.
+type GetSchemaResponse struct {
+	Provider *terraform.ProviderSchema
+}
+
+// ProviderConfigRequest is shared by PrepareProviderConfig and
+// ConfigureProvider: both act on the provider's own configuration block.
+type ProviderConfigRequest struct {
+	Config *terraform.ResourceConfig
+}
+
+// PrepareProviderConfigResponse mirrors the (warnings, errors) pair
+// terraform.ResourceProvider.Validate returns.
+type PrepareProviderConfigResponse struct {
+	Config   *terraform.ResourceConfig
+	Warnings []string
+	Errors   []error
+}
+
+type PlanResourceChangeRequest struct {
+	TypeName   string
+	Info       *terraform.InstanceInfo
+	PriorState *terraform.InstanceState
+	Config     *terraform.ResourceConfig
+}
+
+type PlanResourceChangeResponse struct {
+	PlannedDiff *terraform.InstanceDiff
+}
+
+type ApplyResourceChangeRequest struct {
+	TypeName    string
+	Info        *terraform.InstanceInfo
+	PriorState  *terraform.InstanceState
+	PlannedDiff *terraform.InstanceDiff
+}
+
+type ApplyResourceChangeResponse struct {
+	NewState *terraform.InstanceState
+}
+
+type ReadResourceRequest struct {
+	TypeName   string
+	Info       *terraform.InstanceInfo
+	PriorState *terraform.InstanceState
+}
+
+type ReadResourceResponse struct {
+	NewState *terraform.InstanceState
+}
+
+type ImportResourceStateRequest struct {
+	TypeName string
+	Info     *terraform.InstanceInfo
+	ID       string
+}
+
+type ImportResourceStateResponse struct {
+	States []*terraform.InstanceState
+}
+
+type ReadDataSourceRequest struct {
+	TypeName string
+	Info     *terraform.InstanceInfo
+	Config   *terraform.ResourceConfig
+}
+
+type ReadDataSourceResponse struct {
+	State *terraform.InstanceState
+}
@@ -0,0 +1,54 @@
+package terraform
+
+// ResourceProvisioner is the interface that all provisioners must
+// implement.
+//
+// This interface historically only described provisioners loaded as
+// external go-plugin subprocesses. It's the same interface an in-process
+// provisioner (see command.InternalProvisioners) satisfies, so that core's
+// lookup path doesn't need to know whether a given provisioner came from a
+// subprocess or was constructed directly in this binary.
+type ResourceProvisioner interface {
+	// GetSchema returns the config schema for this provisioner's
+	// `provisioner` block.
+	GetSchema() (*ProvisionerSchema, error)
+
+	// Validate is called once at the beginning with the raw configuration
+	// (no interpolation done) and can return a list of warnings and/or
+	// errors.
+	Validate(*ResourceConfig) ([]string, []error)
+
+	// Apply runs the provisioner against the given resource instance, with
+	// UIOutput provided so the provisioner can stream progress back the
+	// same way a local-exec command's stdout does.
+	Apply(UIOutput, *InstanceState, *ResourceConfig) error
+
+	// Stop is called when the provisioner should halt any in-flight
+	// actions, mirroring ResourceProvider.Stop.
+	Stop() error
+}
+
+// ResourceProvisionerCloser is an interface that provisioners that can
+// close connections that aren't needed anymore must implement.
+type ResourceProvisionerCloser interface {
+	Close() error
+}
+
+// ProvisionerSchema describes a provisioner's configuration schema. It's
+// intentionally minimal next to ProviderSchema: provisioners don't have
+// resources, data sources, or per-resource-type schemas to describe.
+type ProvisionerSchema struct {
+	Version int64
+}
+
+// ResourceProvisionerFactory is a function type that creates a new
+// instance of a resource provisioner, mirroring ResourceProviderFactory.
+type ResourceProvisionerFactory func() (ResourceProvisioner, error)
+
+// ResourceProvisionerFactoryFixed is a helper that creates a
+// ResourceProvisionerFactory that just returns some fixed provisioner.
+func ResourceProvisionerFactoryFixed(p ResourceProvisioner) ResourceProvisionerFactory {
+	return func() (ResourceProvisioner, error) {
+		return p, nil
+	}
+}